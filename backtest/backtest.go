@@ -3,297 +3,346 @@ package backtest
 import (
 	"cmp"
 	"fmt"
-	"log"
-	"math"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
-
-	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
-// Data wraps multipe data types e.g. bars, tape, ... and exposes
-// several methods for easy access.
-type Data struct {
-	bars []marketdata.Bar
-}
-
-type Price string
+type Side string
 
 const (
-	Open  Price = "open"
-	High  Price = "high"
-	Low   Price = "low"
-	Close Price = "close"
+	Buy  Side = "buy"
+	Sell Side = "sell"
 )
 
-// Prices returns list of prices type e.g. open, close ...
-func (d *Data) Prices(p Price) []float64 {
-	var prices []float64
-	for _, b := range d.Bars() {
-		switch p {
-		case Open:
-			prices = append(prices, b.Open)
-		case High:
-			prices = append(prices, b.High)
-		case Low:
-			prices = append(prices, b.Low)
-		default:
-			prices = append(prices, b.Close)
-		}
-
-	}
-	return prices
-}
-
-// Bars returns list of bars.
-func (d *Data) Bars() []marketdata.Bar {
-	return d.bars
+type Backtest struct {
+	opts Opts
+	// symbols are sorted and keys of `data` and `view`.
+	symbols []string
+	data    map[string]*Data
+	// view is shared by all accounts and represents bars up until current index.
+	view       map[string]*Data
+	strategies []*strategy
+	accounts   []*account
+	// err keeps the first error found while registering strategies.
+	err error
 }
 
-// FirstBar returns first bar in bars' list.
-func (d *Data) FirstBar() marketdata.Bar {
-	return d.bars[0]
+// account is an independent set of cash, orders and trades. Each strategy gets its
+// own account unless they opt in to the shared one.
+type account struct {
+	name       string
+	allocation float64
+	broker     *broker
 }
 
-// LastBar returns last bar in bars' list.
-func (d *Data) LastBar() marketdata.Bar {
-	return d.bars[len(d.bars)-1]
-}
+// New is our starting point. This is where we define our config for the backtest.
+// Bars are keyed by symbol and aligned across symbols keeping only timestamps
+// present for all of them. Options are applied on top of the defaults and an error
+// is returned when any of them fails validation. Bars can be fetched from any
+// `DataFeed` e.g. the ones in the `data` package.
+func New(bars map[string][]Bar, opts ...Option) (*Backtest, error) {
+	o := defaultOpts()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
 
-func getBarPrice(bar Bar, p Price) float64 {
-	return map[Price]float64{
-		Open:  bar.Open,
-		High:  bar.High,
-		Low:   bar.Low,
-		Close: bar.Close,
-	}[p]
-}
+	bt := &Backtest{
+		opts:    o,
+		symbols: symbols(bars),
+		data:    make(map[string]*Data, len(bars)),
+		view:    make(map[string]*Data, len(bars)),
+	}
+	for symbol, bs := range align(bars) {
+		bt.data[symbol] = &Data{bars: bs}
+		bt.view[symbol] = &Data{}
+	}
+	if !bt.opts.timeFrame.valid() && len(bt.symbols) > 0 {
+		bt.opts.timeFrame = inferTimeFrame(bt.data[bt.symbols[0]].bars)
+	}
+	for _, tf := range o.resample {
+		for symbol, data := range bt.data {
+			if data.frames == nil {
+				data.frames = make(map[TimeFrame]*frame, len(o.resample))
+				bt.view[symbol].frames = data.frames
+			}
+			data.frames[tf] = newFrame(data.bars, bt.opts.timeFrame, tf, o.session)
+		}
+	}
+	for symbol, ticks := range o.ticks {
+		data, ok := bt.data[symbol]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q has ticks but no bars", ErrUnknownSymbol, symbol)
+		}
+		data.setTicks(symbol, ticks, bt.opts.timeFrame)
+	}
 
-// FirstPrice returns first bar's price e.g. close, close ...
-func (d *Data) FirstPrice(p Price) float64 {
-	return getBarPrice(d.FirstBar(), p)
+	return bt, nil
 }
 
-// LastPrice returns last bar's price e.g. close, close ...
-func (d *Data) LastPrice(p Price) float64 {
-	return getBarPrice(d.LastBar(), p)
+// len returns the number of aligned bars.
+func (bt *Backtest) len() int {
+	if len(bt.symbols) == 0 {
+		return 0
+	}
+	return len(bt.data[bt.symbols[0]].bars)
 }
 
-// FirstClose returns first bar's close price.
-func (d *Data) FirstClose() float64 {
-	return d.FirstBar().Close
+// bars returns the aligned bars of every symbol past the warm-up.
+func (bt *Backtest) bars() map[string][]Bar {
+	bars := make(map[string][]Bar, len(bt.data))
+	for symbol, data := range bt.data {
+		bars[symbol] = data.bars[bt.opts.warmup:]
+	}
+	return bars
 }
 
-// FirstClose returns last bar's close price.
-func (d *Data) LastClose() float64 {
-	return d.LastBar().Close
+// timestamp returns the timestamp of aligned bars at index i.
+func (bt *Backtest) timestamp(i int) time.Time {
+	return bt.data[bt.symbols[0]].BarAt(i).Timestamp
 }
 
-// BarAt returns bar at given index allowing easy backward access.
-//
-//	BarAt(0) // get first bar
-//	BarAt(-1) // get last bar
-func (d *Data) BarAt(i int) marketdata.Bar {
-	if i < 0 {
-		return d.bars[len(d.bars)-(-i)]
+// Strategy is the user's main way of interacting with the lib. It registers a
+// user defined callback under a unique name that we call on each bar passing in
+// an instance of `Strategy` to allow order placement, trade manipulation, data
+// access, ... Call it once per strategy to run several of them in the same backtest.
+func (bt *Backtest) Strategy(name string, cb func(s *Strategy), opts ...StrategyOption) *Backtest {
+	if bt.err != nil {
+		return bt
+	}
+	if name == "" || slices.ContainsFunc(bt.strategies, func(st *strategy) bool { return st.name == name }) {
+		bt.err = fmt.Errorf("%w: strategy name %q must be unique and not empty", ErrInvalidOption, name)
+		return bt
 	}
-	return d.bars[i]
-}
-
-type Side string
-
-const (
-	Buy  Side = "buy"
-	Sell Side = "sell"
-)
 
-// No ideal if we're planning on supporting other maket data providers
-// I guess we'll have to map the data nd contruct the struct ourselves
-type Bar = marketdata.Bar
+	st := &strategy{name: name, cb: cb}
+	for _, opt := range opts {
+		if err := opt(&st.opts); err != nil {
+			bt.err = fmt.Errorf("strategy %s: %w", name, err)
+			return bt
+		}
+	}
+	bt.strategies = append(bt.strategies, st)
 
-type Backtest struct {
-	broker   *broker
-	data     *Data
-	strategy func(s *Strategy) // make this an []StrategyCb instead
+	return bt
 }
 
-type Opts struct {
-	// leverage       float64 -> no sure about this?
-	// This is our starting capital. Defaults to 10,000.00.
-	cash float64
-	// Value between 0 and 1 sets default order size based on perc of capital. Defaults to 3%.
-	orderSize float64
-	// Value between 0 and 1 sets margin
-	margin float64
-	// Value between 0 and 1 sets commision charged per trade
-	commision float64
-	// Enter trade on bar close else default to next bar's open
-	tradeOnClose bool
-	// When true it'll keep only one trade open at a time
-	exclusiveOrder bool
-	// When set to true order size will be treated as `fractional` instead of
-	// `notional` trade. E.g. 0.50 of a shared priced at $200 will create a trade of $100.
-	fractionable bool
-}
+// setupAccounts creates a fresh account per strategy plus one for all strategies
+// sharing an account. Cash not explicitly allocated is split evenly among accounts
+// without an allocation.
+func (bt *Backtest) setupAccounts() error {
+	var shared *account
+	var sharedNames []string
+	bt.accounts = nil
+	for _, st := range bt.strategies {
+		if st.opts.shared {
+			if shared == nil {
+				shared = &account{}
+			}
+			shared.allocation += st.opts.allocation
+			sharedNames = append(sharedNames, st.name)
+			st.account = shared
+			continue
+		}
+		st.account = &account{name: st.name, allocation: st.opts.allocation}
+		bt.accounts = append(bt.accounts, st.account)
+	}
+	if shared != nil {
+		shared.name = strings.Join(sharedNames, "+")
+		bt.accounts = append(bt.accounts, shared)
+	}
 
-// New is our starting point. This is where we define our config for the backtest.
-// TODO: I'm thinking of going the same direction as VectorBt where data fetching
-// is built-in. All the user needs to provide a connection keys to a service e.g. Alpaca, Yahoo...
-func New(bars []Bar, opts Opts) *Backtest {
-	if opts.cash == 0 {
-		opts.cash = 100000.00
+	var allocated float64
+	var unallocated int
+	for _, a := range bt.accounts {
+		allocated += a.allocation
+		if a.allocation == 0 {
+			unallocated++
+		}
 	}
-	if opts.orderSize == 0 {
-		opts.orderSize = 0.03
+	if allocated > 1 {
+		return fmt.Errorf("%w: allocations add up to %f > 1", ErrInvalidOption, allocated)
 	}
 
-	broker := &broker{
-		opts:     opts,
-		cash:     opts.cash,
-		data:     &Data{},
-		equities: make([]float64, len(bars)),
+	for _, a := range bt.accounts {
+		if a.allocation == 0 {
+			a.allocation = (1 - allocated) / float64(unallocated)
+		}
+		if a.allocation <= 0 {
+			return fmt.Errorf("%w: no cash left to allocate to %s", ErrInvalidOption, a.name)
+		}
+		a.broker = newBroker(bt.opts, bt.opts.cash*a.allocation, bt.view, bt.len())
 	}
-	broker.position = &Position{broker: broker}
 
-	return &Backtest{
-		broker: broker,
-		data:   &Data{bars: bars},
+	for _, st := range bt.strategies {
+		st.s = &Strategy{
+			broker:    st.account.broker,
+			series:    make(map[seriesKey]*series),
+			Symbols:   bt.symbols,
+			Data:      bt.view,
+			Positions: st.account.broker.positions,
+		}
 	}
-}
 
-// Strategies is the user's main way of interacting with the lib. It takes
-// a list of user defined callbacks we iterate and pass in an instance of `Strategy`
-// to allow order placement, trade manipulation, data access, ...
-func (bt *Backtest) Strategy(cb func(s *Strategy)) *Backtest {
-	bt.strategy = cb
-	return bt
+	return nil
 }
 
-// Run runs all strategies on each bar.
-// TODO: This needs to change as we provide support for other data types (tape)
-func (bt *Backtest) Run() *Backtest {
+// Run runs all strategies on each bar and returns the results of the run. It stops
+// at the first error returned by any of the accounts e.g. `ErrAccountBlown` or
+// passed to `Strategy.Fail`.
+//
+// On each bar, orders placed up until the previous bar are processed first, then
+// the tape of the bar if any is replayed tick by tick and finally strategies are
+// called with the bar closed. This way orders are never filled with prices the
+// strategy already saw.
+//
+// Orders placed on a bar are thus filled on the next one: market orders at its
+// open, or at the close of the bar they were placed on with `WithTradeOnClose`,
+// and limits and stops once its range reaches them.
+func (bt *Backtest) Run() (*Result, error) {
+	if bt.err != nil {
+		return nil, bt.err
+	}
 	// There's nothing we can do without data
-	if len(bt.data.bars) == 0 {
-		log.Fatalln("There isn't data available for this period.")
+	if bt.len() <= bt.opts.warmup {
+		return nil, ErrNoData
 	}
-
-	s := &Strategy{
-		broker:       bt.broker,
-		Data:         bt.broker.data,
-		Position:     bt.broker.position,
-		Orders:       bt.broker.orders,
-		Trades:       bt.broker.trades,
-		ClosedTrades: bt.broker.closedTrades,
+	if len(bt.strategies) == 0 {
+		return nil, fmt.Errorf("%w: no strategy registered", ErrInvalidOption)
 	}
-
-	for i := range bt.data.bars {
-		// Data represents bars up until current index
-		bt.broker.data.bars = bt.data.bars[:int(float64(i+1))]
-
-		// TODO: Support more than one strategy
-		bt.strategy(s)
-
-		bt.broker.next() // I might rename this from `next` -> `process`
+	if err := bt.setupAccounts(); err != nil {
+		return nil, err
 	}
 
-	return bt
-}
-
-// Summary outputs the stats for strategies.
-func (bt *Backtest) Summary() {
-	equities := bt.broker.equities
-
-	var drawdowns = make([]float64, len(equities))
-	var peak = equities[0]
-	for i, equity := range equities {
-		if peak > equity {
-			drawdowns[i] = equity/peak - 1
-		} else {
-			peak = equity
+	// Bars of symbols with ticks are built tick by tick while replaying the tape
+	// so they need their own copy.
+	forming := make(map[string][]Bar)
+	for symbol, data := range bt.data {
+		if len(data.ticks) > 0 {
+			forming[symbol] = slices.Clone(data.bars)
 		}
 	}
+	onTape := make(map[string]bool)
+	for _, a := range bt.accounts {
+		a.broker.tape = onTape
+	}
 
-	var drawdownDurations []time.Duration
-	var since time.Time
-	for i, dd := range drawdowns {
-		if dd < 0 && since.IsZero() {
-			since = bt.data.Bars()[i].Timestamp
-		} else if dd == 0 && !since.IsZero() {
-			drawdownDurations = append(drawdownDurations, bt.data.Bars()[i].Timestamp.Sub(since))
-			since = time.Time{}
+	// Warm-up bars are only seen through the data of the first bar strategies are
+	// called on.
+	for i := bt.opts.warmup; i < bt.len(); i++ {
+		ticks := tape(bt.data, bt.symbols, i)
+		clear(onTape)
+		for _, t := range ticks {
+			onTape[t.Symbol] = true
 		}
-	}
 
-	var returnsPct []float64
-	var returns []float64
-	var durations []time.Duration
-	var exposure = make([]float64, len(bt.data.bars))
-	for _, t := range bt.broker.closedTrades {
-		returnsPct = append(returnsPct, t.PnlPct())
-		returns = append(returns, t.Pnl())
-		durations = append(durations, t.ExitTime().Sub(t.EntryTime()))
-		for i := t.EntryBar; i <= t.ExitBar; i++ {
-			exposure[i] = 1
+		// Data represents bars up until current index. Bars on the tape start at
+		// their open and are completed by ticks.
+		for symbol, data := range bt.data {
+			view := bt.view[symbol]
+			view.bars = data.bars[:i+1]
+			from, _ := data.barTicks(i)
+			view.ticks = data.ticks[:from]
+			if onTape[symbol] {
+				bar := data.bars[i]
+				forming[symbol][i] = Bar{Timestamp: bar.Timestamp, Open: bar.Open, High: bar.Open, Low: bar.Open, Close: bar.Open}
+				view.bars = forming[symbol][:i+1]
+			}
 		}
-	}
 
-	wrPct := (float64(count(returns, func(e float64) bool { return e > 0 })) /
-		float64(len(bt.broker.closedTrades))) * 100
+		if err := bt.eachBroker((*broker).processOrders); err != nil {
+			return nil, err
+		}
 
-	retPct := (equities[len(equities)-1] - equities[0]) / equities[0] * 100
+		for _, t := range ticks {
+			view := bt.view[t.Symbol]
+			view.ticks = view.ticks[:len(view.ticks)+1]
+			if t.Kind == TradeTick {
+				bar := &view.bars[i]
+				bar.High = max(bar.High, t.Price)
+				bar.Low = min(bar.Low, t.Price)
+				bar.Close = t.Price
+				bar.Volume += t.Size
+				bar.TradeCount++
+			}
+
+			if err := bt.eachBroker(func(b *broker) error { return b.processTick(t) }); err != nil {
+				return nil, err
+			}
+			for _, st := range bt.strategies {
+				if !st.opts.tickEvents {
+					continue
+				}
+				if err := st.call(&t); err != nil {
+					return nil, err
+				}
+			}
+		}
 
-	bhRetPct := (bt.broker.data.LastClose() - bt.broker.data.FirstClose()) /
-		bt.broker.data.FirstClose() * 100
+		// Bar is closed so we swap the one built from ticks for the actual bar, also
+		// in the forming copy so that later bars on the tape see it closed.
+		for symbol := range onTape {
+			bt.view[symbol].bars = bt.data[symbol].bars[:i+1]
+			forming[symbol][i] = bt.data[symbol].bars[i]
+		}
 
-	pos := sumFunc(returns, func(e float64) bool { return e > 0 })
-	neg := math.Abs(sumFunc(returns, func(e float64) bool { return e < 0 }))
-	pf := pos
-	if neg != 0 {
-		pf = pos / neg
-	}
+		if err := bt.eachBroker((*broker).update); err != nil {
+			return nil, err
+		}
 
-	data := [][2]string{
-		{"start", bt.data.FirstBar().Timestamp.String()},
-		{"end", bt.data.LastBar().Timestamp.String()},
-		{"duration", bt.data.LastBar().Timestamp.Sub(bt.data.FirstBar().Timestamp).String()},
-		{"exposure time", fmt.Sprintf("%f%%", mean(exposure)*100)},
-		{"equity final", fmt.Sprintf("$%f", equities[len(equities)-1])},
-		{"equity peak", fmt.Sprintf("$%f", slices.Max(equities))},
-		{"return", fmt.Sprintf("%f%%", retPct)},
-		{"buy & hold return", fmt.Sprintf("%f%%", bhRetPct)},
-		{"max drawdown", fmt.Sprintf("$%f", slices.Min(equities))},
-		{"max drawdown pct", fmt.Sprintf("%f%%", slices.Min(drawdowns)*100)},
-		{"avg. drawdown", fmt.Sprintf("%f%%", mean(drawdowns)*100)},
-		{"max. drawdown duration", slices.Max(drawdownDurations).String()},
-		{"avg. drawdown duration", mean(drawdownDurations).String()},
-		{"# trades", strconv.Itoa(len(bt.broker.closedTrades))},
-		{"win rate", fmt.Sprintf("%f%%", wrPct)},
-		{"best trade", fmt.Sprintf("%f%%", slices.Max(returnsPct)*100)},
-		{"worst trade", fmt.Sprintf("%f%%", slices.Min(returnsPct)*100)},
-		{"avg. trade", fmt.Sprintf("%f%%", mean(returnsPct)*100)},
-		{"max. trade duration", slices.Max(durations).String()},
-		{"avg. trade duration", mean(durations).String()},
-		{"profit factor", fmt.Sprintf("%f", pf)},
-		{"expectancy", fmt.Sprintf("%f%%", mean(returns)*100)},
+		for _, st := range bt.strategies {
+			if err := st.call(nil); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	row := slices.MaxFunc(data, func(a, b [2]string) int {
-		return cmp.Compare(len(a[0]), len(b[0]))
-	})
-	span := len(row[0]) + 1
+	return bt.result(), nil
+}
 
-	var s strings.Builder
-	for _, d := range data {
-		var padding string
-		for i := 0; i < span-(len(d[0])+1); i++ {
-			padding += " "
+// eachBroker calls fn with the broker of every account stopping at the first error.
+func (bt *Backtest) eachBroker(fn func(b *broker) error) error {
+	for _, a := range bt.accounts {
+		if err := fn(a.broker); err != nil {
+			return fmt.Errorf("%s: %w", a.name, err)
 		}
-		s.WriteString(fmt.Sprintf("%s %s: %s\n", padding, cases.Title(language.English).String(d[0]), d[1]))
 	}
+	return nil
+}
 
-	fmt.Println(s.String())
+// combined merges all accounts into a single one for reporting purposes.
+func (bt *Backtest) combined() *broker {
+	b := newBroker(bt.opts, 0, bt.view, bt.len())
+	for _, a := range bt.accounts {
+		for i, equity := range a.broker.equities {
+			b.equities[i] += equity
+			b.exposures[i] += a.broker.exposures[i] * equity
+		}
+		b.cash += a.broker.cash
+		b.trades = append(b.trades, a.broker.trades...)
+		b.closedTrades = append(b.closedTrades, a.broker.closedTrades...)
+		b.history = append(b.history, a.broker.history...)
+		b.fees += a.broker.fees
+		b.slippage += a.broker.slippage
+		b.rejectedOrders += a.broker.rejectedOrders
+		b.marginCalls += a.broker.marginCalls
+	}
+	// Exposures are weighted by each account's equity
+	for i, equity := range b.equities {
+		if equity > 0 {
+			b.exposures[i] /= equity
+		}
+	}
+	slices.SortStableFunc(b.closedTrades, func(a, b *Trade) int {
+		return cmp.Compare(a.ExitBar, b.ExitBar)
+	})
+	slices.SortStableFunc(b.history, func(a, b *Order) int {
+		return cmp.Compare(a.CreatedBar, b.CreatedBar)
+	})
+	return b
 }
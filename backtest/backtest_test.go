@@ -0,0 +1,194 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFillTiming(t *testing.T) {
+	// Opens gap away from the previous close to tell fill prices apart
+	bars := dailyBars(100, 110, 120, 130, 140)
+	for i := range bars {
+		bars[i].Open = bars[i].Close - 5
+		bars[i].Low = bars[i].Open
+	}
+
+	tests := []struct {
+		name         string
+		tradeOnClose bool
+		entryPrice   float64
+		entryBar     int
+		exitPrice    float64
+		exitBar      int
+	}{
+		{"next open", false, 115, 2, 135, 4},
+		{"trade on close", true, 110, 1, 130, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt, err := New(map[string][]Bar{"X": bars}, WithCash(1000), WithTradeOnClose(tt.tradeOnClose))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := bt.Strategy("test", func(s *Strategy) {
+				switch len(s.Data["X"].Bars()) {
+				case 2:
+					s.Buy("X", TradeOpts{Size: 1})
+				case 4:
+					s.Sell("X", TradeOpts{Size: 1})
+				}
+			}).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Trades) != 1 {
+				t.Fatalf("got %d trades, want 1", len(res.Trades))
+			}
+			tr := res.Trades[0]
+			if tr.EntryPrice != tt.entryPrice || tr.EntryBar != tt.entryBar {
+				t.Errorf("entry: got %f at bar %d, want %f at bar %d", tr.EntryPrice, tr.EntryBar, tt.entryPrice, tt.entryBar)
+			}
+			if tr.ExitPrice != tt.exitPrice || tr.ExitBar != tt.exitBar {
+				t.Errorf("exit: got %f at bar %d, want %f at bar %d", tr.ExitPrice, tr.ExitBar, tt.exitPrice, tt.exitBar)
+			}
+		})
+	}
+}
+
+// counter is an indicator counting the bars it's been updated with.
+type counter struct{ n float64 }
+
+func (c *counter) Update(bar Bar) float64 {
+	c.n++
+	return c.n
+}
+
+func TestWarmup(t *testing.T) {
+	bars := dailyBars(100, 101, 102, 103, 104, 105, 106)
+	bt, err := New(map[string][]Bar{"X": bars}, WithCash(1000), WithWarmup(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first []float64
+	res, err := bt.Strategy("test", func(s *Strategy) {
+		values := s.I("X", "count", func() Indicator { return &counter{} })
+		if first == nil {
+			first = values
+			s.Buy("X", TradeOpts{Size: 1})
+		}
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Indicators are caught up with the warm-up bars on the first call
+	if len(first) != 4 || first[3] != 4 {
+		t.Errorf("first call: got %v, want 4 values ending in 4", first)
+	}
+	if len(res.Equity) != 4 || len(res.Bars["X"]) != 4 {
+		t.Fatalf("got %d equity points and %d bars, want 4", len(res.Equity), len(res.Bars["X"]))
+	}
+	st := res.Stats
+	if !st.Start.Equal(bars[3].Timestamp) || !res.Equity[0].Time.Equal(bars[3].Timestamp) {
+		t.Errorf("start: got %s, want %s", st.Start, bars[3].Timestamp)
+	}
+	if res.Equity[0].Equity != 1000 {
+		t.Errorf("first equity: got %f, want 1000", res.Equity[0].Equity)
+	}
+	if want := (106.0/103 - 1) * 100; st.BuyAndHoldReturnPct != want {
+		t.Errorf("buy & hold: got %f, want %f", st.BuyAndHoldReturnPct, want)
+	}
+	// Filled on the next bar's open and held until the end
+	if st.OpenTrades != 1 || st.ExposureTimePct != 75 {
+		t.Errorf("got %d open trades and %f%% exposure, want 1 and 75%%", st.OpenTrades, st.ExposureTimePct)
+	}
+	assertFinite(t, st)
+
+	bt, err = New(map[string][]Bar{"X": bars}, WithWarmup(len(bars)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bt.Strategy("test", func(s *Strategy) {}).Run(); !errors.Is(err, ErrNoData) {
+		t.Errorf("got %v, want %v", err, ErrNoData)
+	}
+	if _, err := New(map[string][]Bar{"X": bars}, WithWarmup(-1)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	type strat struct {
+		name string
+		opts []StrategyOption
+	}
+	tests := []struct {
+		name       string
+		strategies []strat
+		// want is the starting cash of each account by name
+		want map[string]float64
+		err  error
+	}{
+		{"even split", []strat{{"a", nil}, {"b", nil}}, map[string]float64{"a": 500, "b": 500}, nil},
+		{
+			"allocation",
+			[]strat{{"a", []StrategyOption{WithAllocation(0.25)}}, {"b", nil}, {"c", nil}},
+			map[string]float64{"a": 250, "b": 375, "c": 375},
+			nil,
+		},
+		{
+			"shared account",
+			[]strat{{"a", []StrategyOption{WithSharedAccount(), WithAllocation(0.3)}}, {"b", nil}, {"c", []StrategyOption{WithSharedAccount(), WithAllocation(0.2)}}},
+			map[string]float64{"b": 500, "a+c": 500},
+			nil,
+		},
+		{"over allocated", []strat{{"a", []StrategyOption{WithAllocation(0.6)}}, {"b", []StrategyOption{WithAllocation(0.6)}}}, nil, ErrInvalidOption},
+		{"nothing left", []strat{{"a", []StrategyOption{WithAllocation(1)}}, {"b", nil}}, nil, ErrInvalidOption},
+		{"invalid allocation", []strat{{"a", []StrategyOption{WithAllocation(0)}}}, nil, ErrInvalidOption},
+		{"duplicate name", []strat{{"a", nil}, {"a", nil}}, nil, ErrInvalidOption},
+		{"empty name", []strat{{"", nil}}, nil, ErrInvalidOption},
+		{"none", nil, nil, ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt, err := New(map[string][]Bar{"X": dailyBars(100, 100, 110)}, WithCash(1000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, st := range tt.strategies {
+				// Every strategy buys a unit on the first bar
+				bt.Strategy(st.name, buyOnce, st.opts...)
+			}
+			res, err := bt.Run()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			accounts := res.Accounts
+			if len(tt.want) == 1 {
+				accounts = []*Result{res}
+			} else if res.Name != "combined" {
+				t.Errorf("got %q result, want the combined one", res.Name)
+			}
+			if len(accounts) != len(tt.want) {
+				t.Fatalf("got %d accounts, want %d", len(accounts), len(tt.want))
+			}
+			var cash float64
+			for _, a := range accounts {
+				cash += tt.want[a.Name]
+				if got := a.Equity[0].Equity; got != tt.want[a.Name] {
+					t.Errorf("%s: got %f starting equity, want %f", a.Name, got, tt.want[a.Name])
+				}
+			}
+			// Combined results add up all accounts
+			if res.Stats.Trades != 0 || res.Stats.OpenTrades != len(tt.strategies) {
+				t.Errorf("got %d closed and %d open trades, want 0 and %d", res.Stats.Trades, res.Stats.OpenTrades, len(tt.strategies))
+			}
+			if want := cash + 10*float64(len(tt.strategies)); res.Stats.EquityFinal != want {
+				t.Errorf("got %f final equity, want %f", res.Stats.EquityFinal, want)
+			}
+		})
+	}
+}
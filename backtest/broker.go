@@ -2,25 +2,62 @@ package backtest
 
 import (
 	"fmt"
-	"log"
 	"math"
-	"strings"
+	"slices"
 
 	"github.com/google/uuid"
 )
 
 type broker struct {
-	opts         Opts
-	data         *Data
-	position     *Position
-	orders       []*Order
+	opts      Opts
+	data      map[string]*Data
+	positions map[string]*Position
+	orders    []*Order
+	// history keeps every order placed regardless of its status.
+	history      []*Order
 	trades       []*Trade
 	closedTrades []*Trade
 	equities     []float64
-	cash         float64
+	// exposures tracks the market value of open trades as a percentage of equity.
+	exposures []float64
+	cash      float64
+	fees      float64
+	slippage  float64
+
+	rejectedOrders int
+	marginCalls    int
+	// tape holds the symbols whose orders are filled by ticks within the current bar
+	// instead of the bar itself.
+	tape map[string]bool
+}
+
+// newBroker creates a broker with the given starting cash. `data` is shared with
+// the backtest and `n` is the number of bars we'll track equity for.
+func newBroker(opts Opts, cash float64, data map[string]*Data, n int) *broker {
+	b := &broker{
+		opts:      opts,
+		cash:      cash,
+		data:      data,
+		positions: make(map[string]*Position, len(data)),
+		equities:  make([]float64, n),
+		exposures: make([]float64, n),
+	}
+	for symbol := range data {
+		b.positions[symbol] = &Position{broker: b, symbol: symbol}
+	}
+	return b
+}
+
+// barI returns the index of the current bar.
+func (b *broker) barI() int {
+	for _, data := range b.data {
+		return len(data.bars) - 1
+	}
+	return -1
 }
 
 type newOrderOpts struct {
+	symbol string
 	size   float64
 	side   Side
 	stop   float64
@@ -33,9 +70,13 @@ type newOrderOpts struct {
 
 // newOrder adds a new order to the queue, but before doing so, it validates prices
 // and checks for `opts.exclusiveOrder`.
-func (b *broker) newOrder(opts newOrderOpts) *Order {
+func (b *broker) newOrder(opts newOrderOpts) (*Order, error) {
+	data, ok := b.data[opts.symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSymbol, opts.symbol)
+	}
 	if !b.opts.fractionable && opts.size > 1 && !isWhole(opts.size) {
-		log.Fatalf("Can't evaluate fractional size (%f > 1.00) unless `opts.fractionable` is set to `true`\n", opts.size)
+		return nil, fmt.Errorf("%w: can't evaluate size %f > 1.00 unless `opts.fractionable` is set to `true`", ErrFractionalSize, opts.size)
 	}
 
 	var price float64
@@ -44,46 +85,28 @@ func (b *broker) newOrder(opts newOrderOpts) *Order {
 	} else if opts.stop > 0 {
 		price = opts.stop
 	} else {
-		price = b.data.LastClose()
+		price = data.LastClose()
 	}
 	// Assert stop loss < entry < target profit for long position
 	if opts.side == Buy {
-		var msg strings.Builder
-		msg.WriteString("Long orders require: \n")
 		if opts.sl > 0 && opts.tp > 0 &&
 			!(opts.sl < price && opts.tp > price) {
-			fmt.Fprintf(&msg, "Stop Loss (%f) < ", opts.sl)
-			fmt.Fprintf(&msg, "Price (%f) < ", price)
-			fmt.Fprintf(&msg, "Profit Target (%f)", opts.tp)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: long orders require stop loss (%f) < price (%f) < profit target (%f)", ErrInvalidBracket, opts.sl, price, opts.tp)
 		} else if opts.sl > 0 && opts.sl > price {
-			fmt.Fprintf(&msg, "Stop Loss (%f) < ", opts.sl)
-			fmt.Fprintf(&msg, "Price (%f) ", price)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: long orders require stop loss (%f) < price (%f)", ErrInvalidBracket, opts.sl, price)
 		} else if opts.tp > 0 && opts.tp < price {
-			fmt.Fprintf(&msg, "Profit Target (%f) > ", opts.tp)
-			fmt.Fprintf(&msg, "Price (%f) ", price)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: long orders require profit target (%f) > price (%f)", ErrInvalidBracket, opts.tp, price)
 		}
 	}
 	// Assert stop loss > entry > target profit for short position
 	if opts.side == Sell {
-		var msg strings.Builder
-		msg.WriteString("Short orders require: \n")
 		if opts.sl > 0 && opts.tp > 0 &&
 			!(opts.sl > price && opts.tp < price) {
-			fmt.Fprintf(&msg, "Stop Loss (%f) > ", opts.sl)
-			fmt.Fprintf(&msg, "Price (%f) > ", price)
-			fmt.Fprintf(&msg, "Profit Target (%f)", opts.tp)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: short orders require stop loss (%f) > price (%f) > profit target (%f)", ErrInvalidBracket, opts.sl, price, opts.tp)
 		} else if opts.sl > 0 && opts.sl < price {
-			fmt.Fprintf(&msg, "Stop Loss (%f) > ", opts.sl)
-			fmt.Fprintf(&msg, "Price (%f) ", price)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: short orders require stop loss (%f) > price (%f)", ErrInvalidBracket, opts.sl, price)
 		} else if opts.tp > 0 && opts.tp > price {
-			fmt.Fprintf(&msg, "Profit Target (%f) < ", opts.tp)
-			fmt.Fprintf(&msg, "Price (%f) ", price)
-			log.Fatalln(msg)
+			return nil, fmt.Errorf("%w: short orders require profit target (%f) < price (%f)", ErrInvalidBracket, opts.tp, price)
 		}
 	}
 	// Include broker instance if not preset in opts
@@ -92,50 +115,86 @@ func (b *broker) newOrder(opts newOrderOpts) *Order {
 	}
 	// New order
 	order := &Order{
-		Id:     uuid.NewString(),
-		Size:   opts.size,
-		Side:   opts.side,
-		Stop:   opts.stop,
-		Limit:  opts.limit,
-		SL:     opts.sl,
-		TP:     opts.tp,
-		trade:  opts.trade,
-		broker: opts.broker,
+		Id:         uuid.NewString(),
+		Symbol:     opts.symbol,
+		Size:       opts.size,
+		Side:       opts.side,
+		Stop:       opts.stop,
+		Limit:      opts.limit,
+		SL:         opts.sl,
+		TP:         opts.tp,
+		Status:     Pending,
+		CreatedBar: b.barI(),
+		trade:      opts.trade,
+		broker:     opts.broker,
 	}
+	b.history = append(b.history, order)
 	// Prioritize order related to open trades by putting them to top of the queue
 	if opts.trade != nil && opts.trade.indexOf() >= 0 {
 		b.orders = append([]*Order{order}, b.orders...)
 	} else {
+		// Exclusive orders are handled per symbol.
 		if b.opts.exclusiveOrder {
-			for _, o := range b.orders {
-				if o.trade == nil {
-					o.Cancel()
+			for _, o := range slices.Clone(b.orders) {
+				if o.trade == nil && o.Symbol == opts.symbol {
+					if err := o.Cancel(); err != nil {
+						return nil, err
+					}
 				}
 			}
 			for _, t := range b.trades {
-				t.Close()
+				if t.Symbol == opts.symbol {
+					t.Close()
+				}
 			}
 		}
 		b.orders = append(b.orders, order)
 	}
 
-	return order
+	return order, nil
+}
+
+// processOrders evaluates and processes orders in the queue one at a time against
+// the current bar. Orders of symbols on the tape are left for `processTick`.
+func (b *broker) processOrders() error {
+	return b.process(nil)
 }
 
-// processOrders evaluates and processes orders in the queue one at a time.
-func (b *broker) processOrders() {
+// processTick evaluates and processes orders of the tick's symbol against the tick.
+func (b *broker) processTick(t Tick) error {
+	return b.process(&t)
+}
+
+// process evaluates and processes orders in the queue one at a time. When a tick
+// is given, only orders of its symbol are evaluated treating it as a one price bar.
+func (b *broker) process(tick *Tick) error {
 	reprocess := false
-	barI := len(b.data.bars) - 1
-	bar := b.data.bars[barI]
-	prevBar := b.data.bars[max(0, barI-1)]
-	for _, o := range b.orders {
+	barI := b.barI()
+	// Iterate over a snapshot of the queue as filling an order might remove other
+	// orders from it e.g. legs of a closed trade.
+	for _, o := range slices.Clone(b.orders) {
+		if o.indexOf() < 0 {
+			continue
+		}
+		if (tick != nil && o.Symbol != tick.Symbol) || (tick == nil && b.tape[o.Symbol]) {
+			continue
+		}
+		bars := b.data[o.Symbol].bars
+		bar := bars[barI]
+		prevBar := bars[max(0, barI-1)]
+		// There's no previous close to trade on within the tape so ticks are both
+		// the current and previous bar.
+		if tick != nil {
+			bar = tick.bar(o.Side)
+			prevBar = bar
+		}
 		// Stop orders are handle down below in the `else` clause of the limit order
 		// as they become `market` orders once hit. There are instances where an order
 		// can include both `stop` and `limit` prices and be reached/hit within the
 		// same bar. In such instances we prioritize the stop/market order as there's
 		// no predictive way of determining which got hit first `stop/market` or `limit`
-		// unless we take a look at the tape or something more in detail which is outside
-		// the scope of this project for now.
+		// from the bar alone. Provide ticks with `WithTicks` to fill orders in the
+		// order prices were reached instead.
 		if o.Stop > 0 && o.hitAtOt == "" {
 			isStopHit := (o.IsLong() && bar.High >= o.Stop) ||
 				(o.IsShort() && bar.Low <= o.Stop)
@@ -183,7 +242,7 @@ func (b *broker) processOrders() {
 		}
 		// `processedAtBarI` is key in referencing the bar we process the order at.
 		var processedAtBarI int
-		if o.hitAtOt == Market && b.opts.tradeOnClose {
+		if o.hitAtOt == Market && b.opts.tradeOnClose && tick == nil {
 			processedAtBarI = barI - 1
 		} else {
 			processedAtBarI = barI
@@ -191,77 +250,125 @@ func (b *broker) processOrders() {
 		// This ensures we handle fractional and non-fractional orders in accordance with
 		// the user's params and default opts. The main difference it's that fractional
 		// orders are treated as is as opposed to non-fractional orders meaning we don't do
-		// any kind of rounding of the size.
+		// any kind of rounding of the size. Percentage sizes are based off buying power.
 		var size float64
+		buyingPower := b.buyingPower()
 		if b.opts.fractionable {
 			if o.Size == 0 {
-				size = max((b.opts.orderSize*b.cash)/price, 0)
+				size = max((b.opts.orderSize*buyingPower)/price, 0)
 			} else {
 				size = max(o.Size, 0)
 			}
 		} else {
 			if o.Size == 0 {
-				size = max(math.Floor((b.opts.orderSize*b.cash)/price), 0)
+				size = max(math.Floor((b.opts.orderSize*buyingPower)/price), 0)
 			} else if o.Size < 1 {
-				size = max(math.Floor((o.Size*b.cash)/price), 0)
+				size = max(math.Floor((o.Size*buyingPower)/price), 0)
 			} else {
 				size = max(math.Floor(o.Size), 0)
 			}
 		}
-		// TODO: This is not a good solution (IMPROVE ME). Look into context and signals to handle!
+		// Reject orders too small to buy a single unit e.g. a percentage of buying
+		// power below the price.
 		if size == 0 {
-			o.Cancel()
-			fmt.Printf("size: %f, orderSize: %f, cash: %f, price: %f\n", size, b.opts.orderSize, b.cash, price)
+			b.rejectedOrders++
+			if err := o.Cancel(); err != nil {
+				return err
+			}
+			o.Status = Rejected
 			continue
 		}
+		// Market and stop(become market orders) fills are adjusted against us by the
+		// slippage model. Limit orders are filled at their price or better.
+		var slippage float64
+		if o.hitAtOt == Market && b.opts.slippage != nil {
+			slippage = max(b.opts.slippage.Slippage(o.Side, size, price, bars[processedAtBarI]), 0)
+			if o.IsLong() {
+				price += slippage
+			} else {
+				price -= slippage
+			}
+		}
 
 		// Given an order in the opposite side of existing trade(s) we'll want to reduce
 		// and/or close trade(s) given our computed size above ^. Notice we prioritize
 		// order's parent trade before iterating open trades.
 		if o.trade != nil && o.trade.Side != o.Side {
 			if o.trade.indexOf() >= 0 {
-				b.reduceTrade(o.trade, size, price, processedAtBarI)
+				o.fill(size, price, processedAtBarI)
+				if err := b.reduceTrade(o.trade, size, price, slippage, processedAtBarI); err != nil {
+					return err
+				}
 			} else {
-				o.Cancel()
+				o.Status = Canceled
+			}
+			// Order could have been removed along with its parent trade's legs.
+			if o.indexOf() >= 0 {
+				if err := o.remove(); err != nil {
+					return err
+				}
 			}
 			continue
 		}
-		for _, trade := range b.trades {
-			if trade.Side == o.Side {
+		o.fill(size, price, processedAtBarI)
+		for _, trade := range slices.Clone(b.trades) {
+			if trade.Symbol != o.Symbol || trade.Side == o.Side {
 				continue
 			}
 			if size >= trade.Size {
-				b.closeTrade(trade, price, processedAtBarI)
+				if err := b.closeTrade(trade, price, slippage, processedAtBarI); err != nil {
+					return err
+				}
 				size -= trade.Size
 			} else {
-				b.reduceTrade(trade, size, price, processedAtBarI)
+				if err := b.reduceTrade(trade, size, price, slippage, processedAtBarI); err != nil {
+					return err
+				}
 				size = 0
 			}
 			if size == 0 {
 				break
 			}
 		}
+		// Reject the order when what's left of it, once opposite trades are closed, exceeds
+		// our buying power.
+		if size > 0 && size*price > b.buyingPower() {
+			b.rejectedOrders++
+			if err := o.Cancel(); err != nil {
+				return err
+			}
+			// Keep track of the size filled closing trades if any.
+			o.FilledSize -= size
+			o.Status = Rejected
+			continue
+		}
 		// Create new trade with size left following closing of open trades. Notice we're
 		// reprocessing this order right away via recursion if the order itself it's market
 		// order and it includes a stop loss and/or target profit in order to address
 		// legs hit within same bar
 		if size > 0 {
-			b.openTrade(
+			if err := b.openTrade(
+				o.Symbol,
 				o.Side,
 				size,
 				price,
+				slippage,
 				o.SL,
 				o.TP,
 				processedAtBarI,
-			)
+			); err != nil {
+				return err
+			}
 			if o.hitAtOt == Market && (o.SL > 0 || o.TP > 0) {
 				reprocess = true
 			}
 		}
 
 		// Order could have been closed by its parent trade.
-		if o != nil {
-			o.remove()
+		if o.indexOf() >= 0 {
+			if err := o.remove(); err != nil {
+				return err
+			}
 		}
 	}
 	// Recursively go all over queue of orders with the same bar's data in order to
@@ -277,78 +384,127 @@ func (b *broker) processOrders() {
 	//                          <- low @ 15.00
 	//
 	if reprocess {
-		b.processOrders()
+		return b.process(tick)
 	}
+
+	return nil
 }
 
-// equity returns sum of open trade's PnL plus available cash.
+// equity returns sum of open trade's PnL plus available cash. Notice entry fees
+// of open trades have already been deducted from cash.
 func (b *broker) equity() float64 {
 	var sum float64
 	for _, t := range b.trades {
-		sum += t.Pnl()
+		sum += t.grossPnl()
 	}
 	return sum + b.cash
 }
 
+// marketValue returns the absolute value of open trades at the last close.
+func (b *broker) marketValue() float64 {
+	var sum float64
+	for _, t := range b.trades {
+		sum += math.Abs(t.Size * b.data[t.Symbol].LastClose())
+	}
+	return sum
+}
+
+// buyingPower returns the value of new positions we can take given the equity not
+// already used as margin by open trades.
+func (b *broker) buyingPower() float64 {
+	return max(b.equity()-b.marketValue()*b.opts.margin, 0) / b.opts.margin
+}
+
+// charge computes the commission for the given fill and deducts it from cash.
+func (b *broker) charge(size, price float64) float64 {
+	if b.opts.commission == nil {
+		return 0
+	}
+	fee := b.opts.commission.Commission(size, price)
+	b.cash -= fee
+	b.fees += fee
+	return fee
+}
+
 // openTrade creates a new trade and adds stop loss and take profit target when requested.
-func (b *broker) openTrade(side Side, size, price, slPrice, tpPrice float64, processedAtBarI int) {
+func (b *broker) openTrade(symbol string, side Side, size, price, slippage, slPrice, tpPrice float64, processedAtBarI int) error {
 	trade := &Trade{
-		Id:         uuid.NewString(),
-		Size:       size,
-		Side:       side,
-		EntryPrice: price,
-		EntryBar:   processedAtBarI,
-		broker:     b,
+		Id:            uuid.NewString(),
+		Symbol:        symbol,
+		Size:          size,
+		Side:          side,
+		EntryPrice:    price,
+		EntryBar:      processedAtBarI,
+		EntryFee:      b.charge(size, price),
+		EntrySlippage: slippage,
+		broker:        b,
 	}
 	b.trades = append(b.trades, trade)
+	b.slippage += slippage * size
 	// create a S/L order
 	if slPrice > 0 {
-		trade.SetSL(slPrice)
+		if err := trade.SetSL(slPrice); err != nil {
+			return err
+		}
 	}
 	// create T/P order
 	if tpPrice > 0 {
-		trade.SetTP(tpPrice)
+		if err := trade.SetTP(tpPrice); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // closeTrade moves trade to closedTrades list and removes any pending leg order.
-func (b *broker) closeTrade(trade *Trade, price float64, processedAtBarI int) {
+func (b *broker) closeTrade(trade *Trade, price, slippage float64, processedAtBarI int) error {
 	trade.ExitPrice = price
 	trade.ExitBar = processedAtBarI
+	trade.ExitFee = b.charge(trade.Size, price)
+	trade.ExitSlippage = slippage
+	b.slippage += slippage * trade.Size
 
 	i := trade.indexOf()
 	b.closedTrades = append(b.closedTrades, trade)
 	b.trades = append(b.trades[:i], b.trades[i+1:]...)
 
 	for _, o := range trade.legs {
-		if o != nil {
-			o.remove()
+		if o != nil && o.indexOf() >= 0 {
+			if err := o.remove(); err != nil {
+				return err
+			}
+			o.Status = Canceled
 		}
 	}
 
-	// Update cash
-	b.cash += trade.Pnl()
+	// Update cash. Fees were already deducted when charged.
+	b.cash += trade.grossPnl()
+	return nil
 }
 
 // reduceTrade reduces the size of the trade given the size param. This is the case
 // when an order it's hit in the opposite side, yet its size isn't big enough to close
 // the trade. When reducing the trade, we're essentially creating a new trade(entry)
 // with the remaining size and closing the previous one with the reduced size at the given price.
-func (b *broker) reduceTrade(trade *Trade, size, price float64, processedAtBarI int) {
+func (b *broker) reduceTrade(trade *Trade, size, price, slippage float64, processedAtBarI int) error {
 	sizeLeft := trade.Size - size
 
 	// Something it's wrong with the strategy where allocated order's size in the opposite
 	// way is greater than the existing trade.
 	if sizeLeft < 0 {
-		log.Fatalf("Size provided of %f can't be greater than trade size %f\n", size, trade.Size)
+		return fmt.Errorf("%w: size provided of %f can't be greater than trade size %f", ErrInvalidSize, size, trade.Size)
 	}
 
 	// Is size left is 0, then we just have to close the trade as we're reducing its entirety
 	if sizeLeft == 0 {
-		b.closeTrade(trade, price, processedAtBarI)
-		return
+		return b.closeTrade(trade, price, slippage, processedAtBarI)
 	}
 
+	// Entry fee is split proportionally between the closed and remaining trades
+	closedFee := trade.EntryFee * size / trade.Size
+	trade.EntryFee -= closedFee
+
 	// Reduce size for trade and legs
 	trade.Size = sizeLeft
 	for _, o := range trade.legs {
@@ -361,18 +517,17 @@ func (b *broker) reduceTrade(trade *Trade, size, price float64, processedAtBarI
 	closedTrade := *trade
 	closedTrade.Id = uuid.NewString()
 	closedTrade.Size = size
+	closedTrade.EntryFee = closedFee
 	closedTrade.legs = [2]*Order{}
 	b.trades = append(b.trades, &closedTrade)
-	b.closeTrade(&closedTrade, price, processedAtBarI)
+	return b.closeTrade(&closedTrade, price, slippage, processedAtBarI)
 }
 
-// next processes orders and update cash and equity
-func (b *broker) next() {
-	// Process orders on each bar
-	b.processOrders()
-
+// update tracks equity and exposure at the current bar's close and issues a margin
+// call when needed.
+func (b *broker) update() error {
 	// Last bar index
-	i := len(b.data.bars) - 1
+	i := b.barI()
 
 	// Calculate equity on each bar
 	equity := b.equity()
@@ -380,16 +535,34 @@ func (b *broker) next() {
 	// Track equity curve on each bar iteration
 	b.equities[i] = equity
 
-	// Close all trades once account is blown-up
-	if equity <= 0 {
-		for _, t := range b.trades {
-			b.closeTrade(t, b.data.LastBar().Close, i)
+	// Margin call: liquidate all trades at the bar's close once equity drops below
+	// the maintenance margin of open positions.
+	if len(b.trades) > 0 && equity <= b.marketValue()*b.opts.maintenanceMargin {
+		b.marginCalls++
+		for _, t := range slices.Clone(b.trades) {
+			if err := b.closeTrade(t, b.data[t.Symbol].LastClose(), 0, i); err != nil {
+				return err
+			}
+		}
+		for _, o := range slices.Clone(b.orders) {
+			if err := o.Cancel(); err != nil {
+				return err
+			}
 		}
 
-		// Update account
-		b.cash = 0
+		equity = b.equity()
+		b.equities[i] = equity
+	}
 
-		// Exit program
-		log.Fatalf("You blew up your account!\n")
+	// Track gross exposure of open trades
+	if equity > 0 {
+		b.exposures[i] = b.marketValue() / equity
 	}
+
+	// There's nothing left to trade with once account is blown-up
+	if equity <= 0 {
+		return fmt.Errorf("%w: equity of %f at bar %d", ErrAccountBlown, equity, i)
+	}
+
+	return nil
 }
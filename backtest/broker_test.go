@@ -0,0 +1,129 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestZeroSizeRejected(t *testing.T) {
+	// 1% of $1000 can't buy a single unit at $100
+	res := runBars(t, dailyBars(100, 100, 100), func(s *Strategy) {
+		if len(s.Data["X"].Bars()) == 1 {
+			s.Buy("X", TradeOpts{Size: 0.01})
+		}
+	})
+	if res.Stats.RejectedOrders != 1 {
+		t.Errorf("got %d rejected orders, want 1", res.Stats.RejectedOrders)
+	}
+	if o := res.Orders[0]; o.Status != Rejected || o.FilledSize != 0 {
+		t.Errorf("got %s order filled with %f, want it rejected", o.Status, o.FilledSize)
+	}
+	if res.Stats.OpenTrades != 0 {
+		t.Errorf("got %d open trades, want none", res.Stats.OpenTrades)
+	}
+}
+
+func TestBuyingPower(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		size     float64
+		want     float64
+		rejected int
+	}{
+		{"within cash", nil, 10, 10, 0},
+		{"above cash", nil, 11, 0, 1},
+		{"leveraged", []Option{WithMargin(0.5)}, 20, 20, 0},
+		{"above leverage", []Option{WithMargin(0.5)}, 21, 0, 1},
+		// Percentages are of buying power
+		{"percentage", []Option{WithMargin(0.5)}, 0.5, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runBars(t, dailyBars(100, 100, 100), func(s *Strategy) {
+				if len(s.Data["X"].Bars()) == 1 {
+					s.Buy("X", TradeOpts{Size: tt.size})
+				}
+			}, tt.opts...)
+
+			if res.Stats.RejectedOrders != tt.rejected {
+				t.Errorf("got %d rejected orders, want %d", res.Stats.RejectedOrders, tt.rejected)
+			}
+			if o := res.Orders[0]; o.FilledSize != tt.want {
+				t.Errorf("got %s order filled with %f, want %f", o.Status, o.FilledSize, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuyingPowerUsed checks buying power left by open trades and that orders
+// closing trades aren't rejected for it.
+func TestBuyingPowerUsed(t *testing.T) {
+	res := runBars(t, dailyBars(100, 100, 100, 100, 100), func(s *Strategy) {
+		switch len(s.Data["X"].Bars()) {
+		case 1:
+			s.Buy("X", TradeOpts{Size: 6})
+		case 2:
+			// Only 4 units are left to buy
+			s.Buy("X", TradeOpts{Size: 5})
+		case 3:
+			// Closes the 6 units and sells 4 short
+			s.Sell("X", TradeOpts{Size: 10})
+		}
+	})
+
+	if res.Stats.RejectedOrders != 1 || res.Orders[1].Status != Rejected {
+		t.Errorf("got %d rejected orders, want the second one rejected", res.Stats.RejectedOrders)
+	}
+	if o := res.Orders[2]; o.Status != Filled || o.FilledSize != 10 {
+		t.Errorf("got %s order filled with %f, want 10 filled", o.Status, o.FilledSize)
+	}
+	if n := res.Stats.OpenTrades; n != 1 {
+		t.Errorf("got %d open trades, want the short one", n)
+	}
+}
+
+func TestMarginCall(t *testing.T) {
+	// Buys 20 units at 100 with $1000 at 2:1 leverage
+	opts := []Option{WithMargin(0.5), WithMaintenanceMargin(0.25)}
+	buy := func(s *Strategy) {
+		if len(s.Data["X"].Bars()) == 1 {
+			s.Buy("X", TradeOpts{Size: 20})
+		}
+	}
+
+	// At 70 equity of 400 is still above 25% of the position's 1400
+	res := runBars(t, dailyBars(100, 100, 70, 80), buy, opts...)
+	if res.Stats.MarginCalls != 0 || res.Stats.OpenTrades != 1 {
+		t.Errorf("got %d margin calls and %d open trades, want none and 1", res.Stats.MarginCalls, res.Stats.OpenTrades)
+	}
+
+	// At 65 equity of 300 is below 25% of the position's 1300
+	res = runBars(t, dailyBars(100, 100, 70, 65, 80), buy, opts...)
+	if res.Stats.MarginCalls != 1 || res.Stats.OpenTrades != 0 {
+		t.Fatalf("got %d margin calls and %d open trades, want 1 and none", res.Stats.MarginCalls, res.Stats.OpenTrades)
+	}
+	// Liquidated at the close of the bar
+	if trade := res.Trades[0]; trade.ExitPrice != 65 || trade.ExitBar != 3 {
+		t.Errorf("got trade closed at %f on bar %d, want 65 on bar 3", trade.ExitPrice, trade.ExitBar)
+	}
+	if res.Stats.EquityFinal != 300 {
+		t.Errorf("final equity: got %f, want 300", res.Stats.EquityFinal)
+	}
+}
+
+func TestAccountBlown(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 100, 45, 50)}, WithCash(1000), WithMargin(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20 units bought at 100 lose 1100 at 45
+	_, err = bt.Strategy("test", func(s *Strategy) {
+		if len(s.Data["X"].Bars()) == 1 {
+			s.Buy("X", TradeOpts{Size: 20})
+		}
+	}).Run()
+	if !errors.Is(err, ErrAccountBlown) {
+		t.Errorf("got %v, want %v", err, ErrAccountBlown)
+	}
+}
@@ -0,0 +1,65 @@
+package backtest
+
+import "math"
+
+// CommissionModel computes the fee charged by the broker on every fill given the
+// filled size (units) and price.
+type CommissionModel interface {
+	Commission(size, price float64) float64
+}
+
+// PercentCommission charges a percentage of the fill's notional value (size × price)
+// e.g. PercentCommission(0.001) charges 0.1%.
+type PercentCommission float64
+
+func (c PercentCommission) Commission(size, price float64) float64 {
+	return math.Abs(size*price) * float64(c)
+}
+
+// FixedCommission charges a flat fee per fill regardless of its size.
+type FixedCommission float64
+
+func (c FixedCommission) Commission(size, price float64) float64 {
+	return float64(c)
+}
+
+// PerShareCommission charges `Rate` per unit bounded by `Min` and `Max` per fill.
+// A zero `Max` means there's no upper bound. Similar to IBKR's fixed pricing.
+type PerShareCommission struct {
+	Rate float64
+	Min  float64
+	Max  float64
+}
+
+func (c PerShareCommission) Commission(size, price float64) float64 {
+	fee := math.Abs(size) * c.Rate
+	if c.Max > 0 {
+		fee = min(fee, c.Max)
+	}
+	return max(fee, c.Min)
+}
+
+// CommissionTier is a notional bracket of `TieredCommission`. A zero `UpTo` means
+// there's no upper bound for the tier.
+type CommissionTier struct {
+	UpTo float64
+	Rate float64
+}
+
+// TieredCommission charges a percentage of the fill's notional value where the
+// rate depends on the tier the notional falls in. Tiers are expected in ascending
+// order by `UpTo` and the last tier is used when the notional exceeds all of them.
+type TieredCommission []CommissionTier
+
+func (c TieredCommission) Commission(size, price float64) float64 {
+	if len(c) == 0 {
+		return 0
+	}
+	notional := math.Abs(size * price)
+	for _, tier := range c {
+		if tier.UpTo == 0 || notional <= tier.UpTo {
+			return notional * tier.Rate
+		}
+	}
+	return notional * c[len(c)-1].Rate
+}
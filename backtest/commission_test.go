@@ -0,0 +1,69 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCommissionModels(t *testing.T) {
+	tiers := TieredCommission{{UpTo: 1000, Rate: 0.01}, {UpTo: 10000, Rate: 0.005}, {Rate: 0.001}}
+	tests := []struct {
+		name  string
+		model CommissionModel
+		size  float64
+		price float64
+		want  float64
+	}{
+		{"percent", PercentCommission(0.001), 10, 50, 0.5},
+		{"percent of a short", PercentCommission(0.001), -10, 50, 0.5},
+		{"fixed", FixedCommission(1.5), 1000, 50, 1.5},
+		{"per share", PerShareCommission{Rate: 0.005, Min: 1, Max: 10}, 400, 50, 2},
+		{"per share below min", PerShareCommission{Rate: 0.005, Min: 1, Max: 10}, 10, 50, 1},
+		{"per share above max", PerShareCommission{Rate: 0.005, Min: 1, Max: 10}, 10000, 50, 10},
+		{"per share without max", PerShareCommission{Rate: 0.005}, 10000, 50, 50},
+		{"first tier", tiers, 10, 50, 5},
+		{"tier upper bound", tiers, 20, 50, 10},
+		{"second tier", tiers, 100, 50, 25},
+		{"unbounded tier", tiers, 1000, 50, 50},
+		{"past bounded tiers", TieredCommission{{UpTo: 1000, Rate: 0.01}}, 100, 50, 50},
+		{"no tiers", TieredCommission{}, 100, 50, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Commission(tt.size, tt.price); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionCharged(t *testing.T) {
+	// Buys 2 at 100 and sells 1 at 110 holding the other one
+	res := runBars(t, dailyBars(100, 100, 110, 110), func(s *Strategy) {
+		switch len(s.Data["X"].Bars()) {
+		case 1:
+			s.Buy("X", TradeOpts{Size: 2})
+		case 3:
+			s.Sell("X", TradeOpts{Size: 1})
+		}
+	}, WithCommission(FixedCommission(1)))
+
+	if len(res.Trades) != 1 {
+		t.Fatalf("got %d closed trades, want 1", len(res.Trades))
+	}
+	// The entry fee is split between the closed and open halves of the trade
+	trade := res.Trades[0]
+	if trade.EntryFee != 0.5 || trade.ExitFee != 1 || trade.Fees() != 1.5 {
+		t.Errorf("fees: got %f on entry and %f on exit, want 0.5 and 1", trade.EntryFee, trade.ExitFee)
+	}
+	if want := 10 - 1.5; math.Abs(trade.Pnl()-want) > 1e-9 {
+		t.Errorf("pnl: got %f, want %f", trade.Pnl(), want)
+	}
+	if res.Stats.Fees != 2 {
+		t.Errorf("total fees: got %f, want 2", res.Stats.Fees)
+	}
+	// Both units gained 10 and fees are deducted from cash as they're charged
+	if want := 1000 + 20 - 2.0; math.Abs(res.Stats.EquityFinal-want) > 1e-9 {
+		t.Errorf("final equity: got %f, want %f", res.Stats.EquityFinal, want)
+	}
+}
@@ -0,0 +1,222 @@
+package backtest
+
+import (
+	"slices"
+	"time"
+)
+
+// Bar is an OHLCV bar. It's provider agnostic so data feeds are in charge of
+// mapping their own bars to it.
+type Bar struct {
+	Timestamp  time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     float64
+	VWAP       float64
+	TradeCount uint64
+}
+
+// Data wraps multipe data types e.g. bars, tape, ... and exposes
+// several methods for easy access.
+type Data struct {
+	bars  []Bar
+	ticks []Tick
+	// tickEnds holds for each bar the index right after its last tick.
+	tickEnds []int
+	// frames are bars resampled to higher time frames. Shared by the data and its
+	// view as only completed bars up until the last one are visible.
+	frames map[TimeFrame]*frame
+	// prices caches `Prices` by type as they're requested on every bar.
+	prices map[Price][]float64
+}
+
+type Price string
+
+const (
+	Open  Price = "open"
+	High  Price = "high"
+	Low   Price = "low"
+	Close Price = "close"
+	// Volume, VWAP and TradeCount are 0 unless the data feed provides them.
+	Volume     Price = "volume"
+	VWAP       Price = "vwap"
+	TradeCount Price = "trade_count"
+)
+
+// Price returns the bar's price of the given type e.g. open, close, volume ... Unknown
+// types default to close.
+func (b Bar) Price(p Price) float64 {
+	switch p {
+	case Open:
+		return b.Open
+	case High:
+		return b.High
+	case Low:
+		return b.Low
+	case Volume:
+		return b.Volume
+	case VWAP:
+		return b.VWAP
+	case TradeCount:
+		return float64(b.TradeCount)
+	default:
+		return b.Close
+	}
+}
+
+// Prices returns list of prices type e.g. open, close, volume ... Prices are cached and
+// only those of new bars are added on each call so the returned slice must not be
+// modified.
+func (d *Data) Prices(p Price) []float64 {
+	if d.prices == nil {
+		d.prices = make(map[Price][]float64)
+	}
+	// The last bar may still be forming from ticks so it's never cached
+	n := len(d.bars)
+	prices := d.prices[p]
+	prices = prices[:min(len(prices), max(n-1, 0))]
+	for _, b := range d.bars[len(prices):] {
+		prices = append(prices, b.Price(p))
+	}
+	d.prices[p] = prices
+	return prices[:n:n]
+}
+
+// Bars returns list of bars.
+func (d *Data) Bars() []Bar {
+	return d.bars
+}
+
+// FirstBar returns first bar in bars' list.
+func (d *Data) FirstBar() Bar {
+	return d.bars[0]
+}
+
+// LastBar returns last bar in bars' list.
+func (d *Data) LastBar() Bar {
+	return d.bars[len(d.bars)-1]
+}
+
+// FirstPrice returns first bar's price e.g. close, close ...
+func (d *Data) FirstPrice(p Price) float64 {
+	return d.FirstBar().Price(p)
+}
+
+// LastPrice returns last bar's price e.g. close, close ...
+func (d *Data) LastPrice(p Price) float64 {
+	return d.LastBar().Price(p)
+}
+
+// FirstClose returns first bar's close price.
+func (d *Data) FirstClose() float64 {
+	return d.FirstBar().Close
+}
+
+// FirstClose returns last bar's close price.
+func (d *Data) LastClose() float64 {
+	return d.LastBar().Close
+}
+
+// Resampled returns bars resampled to the given higher time frame. Only periods
+// completed by the last bar are included so strategies never see a period still
+// in the making. It returns nil unless the time frame is registered with `WithResample`.
+//
+//	hourly := s.Data["SPY"].Resampled(backtest.NewTimeFrame(1, backtest.Hour))
+//	sma := indicators.SMA(20, hourly.Prices(backtest.Close))
+func (d *Data) Resampled(tf TimeFrame) *Data {
+	f, ok := d.frames[tf]
+	if !ok {
+		return nil
+	}
+	n := 0
+	if len(d.bars) > 0 {
+		n = f.completed[len(d.bars)-1]
+	}
+	return &Data{bars: f.bars[:n]}
+}
+
+// Ticks returns list of ticks up until the current one. It's empty unless ticks
+// are provided with `WithTicks`.
+func (d *Data) Ticks() []Tick {
+	return d.ticks
+}
+
+// LastTick returns the last tick in ticks' list. It reports false when there are
+// no ticks yet.
+func (d *Data) LastTick() (Tick, bool) {
+	if len(d.ticks) == 0 {
+		return Tick{}, false
+	}
+	return d.ticks[len(d.ticks)-1], true
+}
+
+// setTicks sorts ticks and assigns them to bars of the given time frame. Ticks
+// before the first bar or after the last one are dropped as there's no bar to
+// assign them to.
+func (d *Data) setTicks(symbol string, ticks []Tick, tf TimeFrame) {
+	ticks = sortTicks(symbol, ticks)
+	if len(d.bars) > 0 {
+		first := d.bars[0].Timestamp
+		i, _ := slices.BinarySearchFunc(ticks, first, func(t Tick, ts time.Time) int {
+			return t.Timestamp.Compare(ts)
+		})
+		ticks = ticks[i:]
+	}
+	d.tickEnds = tickEnds(d.bars, ticks, tf)
+	if n := len(d.tickEnds); n > 0 {
+		ticks = ticks[:d.tickEnds[n-1]]
+	}
+	d.ticks = ticks
+}
+
+// barTicks returns the range of ticks within bar i.
+func (d *Data) barTicks(i int) (from, to int) {
+	if len(d.tickEnds) == 0 {
+		return 0, 0
+	}
+	if i > 0 {
+		from = d.tickEnds[i-1]
+	}
+	return from, d.tickEnds[i]
+}
+
+// BarAt returns bar at given index allowing easy backward access.
+//
+//	BarAt(0) // get first bar
+//	BarAt(-1) // get last bar
+func (d *Data) BarAt(i int) Bar {
+	if i < 0 {
+		return d.bars[len(d.bars)-(-i)]
+	}
+	return d.bars[i]
+}
+
+// align keeps bars at timestamps present for all symbols so that bar indexes are
+// shared across symbols. Bars are sorted by timestamp and duplicates dropped.
+func align(bars map[string][]Bar) map[string][]Bar {
+	counts := make(map[time.Time]int)
+	sorted := make(map[string][]Bar, len(bars))
+	for symbol, bs := range bars {
+		bs = slices.Clone(bs)
+		slices.SortStableFunc(bs, func(a, b Bar) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		bs = slices.CompactFunc(bs, func(a, b Bar) bool {
+			return a.Timestamp.Equal(b.Timestamp)
+		})
+		for _, b := range bs {
+			counts[b.Timestamp.UTC()]++
+		}
+		sorted[symbol] = bs
+	}
+
+	aligned := make(map[string][]Bar, len(bars))
+	for symbol, bs := range sorted {
+		aligned[symbol] = slices.DeleteFunc(bs, func(b Bar) bool {
+			return counts[b.Timestamp.UTC()] != len(bars)
+		})
+	}
+	return aligned
+}
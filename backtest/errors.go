@@ -0,0 +1,31 @@
+package backtest
+
+import "errors"
+
+// Errors returned by the backtest. They're meant to be checked with `errors.Is`
+// as most of them are wrapped with additional context e.g. prices or sizes.
+var (
+	// ErrInvalidOption is returned by `New` when an option fails validation.
+	ErrInvalidOption = errors.New("invalid option")
+	// ErrNoData is returned by `Run` when there are no bars to iterate.
+	ErrNoData = errors.New("no data available for this period")
+	// ErrUnknownSymbol is returned when placing an order for a symbol without data.
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	// ErrInvalidBracket is returned when stop loss and/or take profit prices are on
+	// the wrong side of the entry price.
+	ErrInvalidBracket = errors.New("invalid bracket order")
+	// ErrFractionalSize is returned when a fractional size > 1 is requested while
+	// `fractionable` is disabled.
+	ErrFractionalSize = errors.New("fractional size not allowed")
+	// ErrInvalidPrice is returned when a price <= 0 is given for a contingent order.
+	ErrInvalidPrice = errors.New("invalid price")
+	// ErrInvalidSize is returned when reducing a trade by more than its size.
+	ErrInvalidSize = errors.New("invalid size")
+	// ErrOrderNotFound is returned when an order is no longer in the queue.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrAccountBlown is returned by `Run` once equity drops to 0 or below.
+	ErrAccountBlown = errors.New("account blown up")
+	// ErrNoTrials is returned by `Optimize` when constraints or filters leave no
+	// run to rank.
+	ErrNoTrials = errors.New("no trials")
+)
@@ -0,0 +1,219 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// tradeRecord is the exported representation of a closed trade.
+type tradeRecord struct {
+	Id            string    `json:"id"`
+	Symbol        string    `json:"symbol"`
+	Side          Side      `json:"side"`
+	Size          float64   `json:"size"`
+	EntryPrice    float64   `json:"entry_price"`
+	ExitPrice     float64   `json:"exit_price"`
+	EntryTime     time.Time `json:"entry_time"`
+	ExitTime      time.Time `json:"exit_time"`
+	Pnl           float64   `json:"pnl"`
+	PnlPct        float64   `json:"pnl_pct"`
+	EntryFee      float64   `json:"entry_fee"`
+	ExitFee       float64   `json:"exit_fee"`
+	EntrySlippage float64   `json:"entry_slippage"`
+	ExitSlippage  float64   `json:"exit_slippage"`
+}
+
+// orderRecord is the exported representation of an order.
+type orderRecord struct {
+	Id          string      `json:"id"`
+	Symbol      string      `json:"symbol"`
+	Side        Side        `json:"side"`
+	Size        float64     `json:"size"`
+	Stop        float64     `json:"stop"`
+	Limit       float64     `json:"limit"`
+	SL          float64     `json:"sl"`
+	TP          float64     `json:"tp"`
+	Contingent  bool        `json:"contingent"`
+	Status      OrderStatus `json:"status"`
+	CreatedTime time.Time   `json:"created_time"`
+	FilledTime  *time.Time  `json:"filled_time"`
+	FillPrice   float64     `json:"fill_price"`
+	FilledSize  float64     `json:"filled_size"`
+}
+
+// equityRecord is the exported representation of an equity point.
+type equityRecord struct {
+	Time        time.Time `json:"time"`
+	Equity      float64   `json:"equity"`
+	DrawdownPct float64   `json:"drawdown_pct"`
+}
+
+func (r *Result) tradeRecords() []tradeRecord {
+	records := make([]tradeRecord, len(r.Trades))
+	for i, t := range r.Trades {
+		records[i] = tradeRecord{
+			Id:            t.Id,
+			Symbol:        t.Symbol,
+			Side:          t.Side,
+			Size:          t.Size,
+			EntryPrice:    t.EntryPrice,
+			ExitPrice:     t.ExitPrice,
+			EntryTime:     t.EntryTime(),
+			ExitTime:      t.ExitTime(),
+			Pnl:           t.Pnl(),
+			PnlPct:        t.PnlPct() * 100,
+			EntryFee:      t.EntryFee,
+			ExitFee:       t.ExitFee,
+			EntrySlippage: t.EntrySlippage,
+			ExitSlippage:  t.ExitSlippage,
+		}
+	}
+	return records
+}
+
+func (r *Result) orderRecords() []orderRecord {
+	records := make([]orderRecord, len(r.Orders))
+	for i, o := range r.Orders {
+		var filledTime *time.Time
+		if t := o.FilledTime(); !t.IsZero() {
+			filledTime = &t
+		}
+		records[i] = orderRecord{
+			Id:          o.Id,
+			Symbol:      o.Symbol,
+			Side:        o.Side,
+			Size:        o.Size,
+			Stop:        o.Stop,
+			Limit:       o.Limit,
+			SL:          o.SL,
+			TP:          o.TP,
+			Contingent:  o.IsContingent(),
+			Status:      o.Status,
+			CreatedTime: o.CreatedTime(),
+			FilledTime:  filledTime,
+			FillPrice:   o.FillPrice,
+			FilledSize:  o.FilledSize,
+		}
+	}
+	return records
+}
+
+func (r *Result) equityRecords() []equityRecord {
+	records := make([]equityRecord, len(r.Equity))
+	for i, e := range r.Equity {
+		records[i] = equityRecord(e)
+	}
+	return records
+}
+
+// WriteTradesCSV writes the trade log to w as CSV with a header row.
+func (r *Result) WriteTradesCSV(w io.Writer) error {
+	rows := [][]string{{
+		"id", "symbol", "side", "size", "entry_price", "exit_price", "entry_time", "exit_time",
+		"pnl", "pnl_pct", "entry_fee", "exit_fee", "entry_slippage", "exit_slippage",
+	}}
+	for _, t := range r.tradeRecords() {
+		rows = append(rows, []string{
+			t.Id,
+			t.Symbol,
+			string(t.Side),
+			formatFloat(t.Size),
+			formatFloat(t.EntryPrice),
+			formatFloat(t.ExitPrice),
+			formatTime(t.EntryTime),
+			formatTime(t.ExitTime),
+			formatFloat(t.Pnl),
+			formatFloat(t.PnlPct),
+			formatFloat(t.EntryFee),
+			formatFloat(t.ExitFee),
+			formatFloat(t.EntrySlippage),
+			formatFloat(t.ExitSlippage),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// WriteTradesJSON writes the trade log to w as a JSON array.
+func (r *Result) WriteTradesJSON(w io.Writer) error {
+	return writeJSON(w, r.tradeRecords())
+}
+
+// WriteOrdersCSV writes the order log to w as CSV with a header row.
+func (r *Result) WriteOrdersCSV(w io.Writer) error {
+	rows := [][]string{{
+		"id", "symbol", "side", "size", "stop", "limit", "sl", "tp", "contingent", "status",
+		"created_time", "filled_time", "fill_price", "filled_size",
+	}}
+	for _, o := range r.orderRecords() {
+		var filledTime string
+		if o.FilledTime != nil {
+			filledTime = formatTime(*o.FilledTime)
+		}
+		rows = append(rows, []string{
+			o.Id,
+			o.Symbol,
+			string(o.Side),
+			formatFloat(o.Size),
+			formatFloat(o.Stop),
+			formatFloat(o.Limit),
+			formatFloat(o.SL),
+			formatFloat(o.TP),
+			strconv.FormatBool(o.Contingent),
+			string(o.Status),
+			formatTime(o.CreatedTime),
+			filledTime,
+			formatFloat(o.FillPrice),
+			formatFloat(o.FilledSize),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// WriteOrdersJSON writes the order log to w as a JSON array.
+func (r *Result) WriteOrdersJSON(w io.Writer) error {
+	return writeJSON(w, r.orderRecords())
+}
+
+// WriteEquityCSV writes the per bar equity and drawdown curve to w as CSV with a
+// header row.
+func (r *Result) WriteEquityCSV(w io.Writer) error {
+	rows := [][]string{{"time", "equity", "drawdown_pct"}}
+	for _, e := range r.equityRecords() {
+		rows = append(rows, []string{
+			formatTime(e.Time),
+			formatFloat(e.Equity),
+			formatFloat(e.DrawdownPct),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// WriteEquityJSON writes the per bar equity and drawdown curve to w as a JSON array.
+func (r *Result) WriteEquityJSON(w io.Writer) error {
+	return writeJSON(w, r.equityRecords())
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
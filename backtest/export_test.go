@@ -0,0 +1,169 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// exportRun runs a round trip charging $1 per fill and leaves a limit order
+// pending at the end.
+func exportRun(t *testing.T) *Result {
+	t.Helper()
+	return runBars(t, dailyBars(100, 100, 110, 110), func(s *Strategy) {
+		roundTrip(s)
+		if len(s.Data["X"].Bars()) == 4 {
+			s.Buy("X", TradeOpts{Size: 1, Limit: 50})
+		}
+	}, WithCommission(FixedCommission(1)))
+}
+
+func readCSV(t *testing.T, s string) []map[string]string {
+	t.Helper()
+	rows, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]string
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(row))
+		for i, v := range row {
+			record[rows[0][i]] = v
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestExportTrades(t *testing.T) {
+	res := exportRun(t)
+	want := map[string]string{
+		"id":             res.Trades[0].Id,
+		"symbol":         "X",
+		"side":           "buy",
+		"size":           "1",
+		"entry_price":    "100",
+		"exit_price":     "110",
+		"entry_time":     "2024-01-02T00:00:00Z",
+		"exit_time":      "2024-01-04T00:00:00Z",
+		"pnl":            "8",
+		"pnl_pct":        "8",
+		"entry_fee":      "1",
+		"exit_fee":       "1",
+		"entry_slippage": "0",
+		"exit_slippage":  "0",
+	}
+
+	var sb strings.Builder
+	if err := res.WriteTradesCSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	records := readCSV(t, sb.String())
+	if len(records) != 1 {
+		t.Fatalf("csv: got %d trades, want 1", len(records))
+	}
+	for k, v := range want {
+		if records[0][k] != v {
+			t.Errorf("csv %s: got %q, want %q", k, records[0][k], v)
+		}
+	}
+
+	sb.Reset()
+	if err := res.WriteTradesJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+	var trades []map[string]any
+	if err := json.Unmarshal([]byte(sb.String()), &trades); err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 1 {
+		t.Fatalf("json: got %d trades, want 1", len(trades))
+	}
+	for k, v := range want {
+		// Numbers are written as numbers in JSON
+		if got, ok := trades[0][k].(float64); ok && formatFloat(got) == v {
+			continue
+		}
+		if trades[0][k] != v {
+			t.Errorf("json %s: got %v, want %s", k, trades[0][k], v)
+		}
+	}
+}
+
+func TestExportOrders(t *testing.T) {
+	res := exportRun(t)
+
+	var sb strings.Builder
+	if err := res.WriteOrdersCSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	records := readCSV(t, sb.String())
+	if len(records) != 3 {
+		t.Fatalf("csv: got %d orders, want 3", len(records))
+	}
+	if o := records[1]; o["side"] != "sell" || o["status"] != "filled" || o["created_time"] != "2024-01-03T00:00:00Z" || o["filled_time"] != "2024-01-04T00:00:00Z" || o["fill_price"] != "110" || o["filled_size"] != "1" {
+		t.Errorf("csv: got %v, want the sell filled at 110", o)
+	}
+	// Pending orders have no fill
+	if o := records[2]; o["status"] != "pending" || o["limit"] != "50" || o["filled_time"] != "" || o["filled_size"] != "0" {
+		t.Errorf("csv: got %v, want the limit order pending", o)
+	}
+
+	sb.Reset()
+	if err := res.WriteOrdersJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+	var orders []map[string]any
+	if err := json.Unmarshal([]byte(sb.String()), &orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 3 {
+		t.Fatalf("json: got %d orders, want 3", len(orders))
+	}
+	if o := orders[0]; o["filled_time"] != "2024-01-02T00:00:00Z" || o["fill_price"] != 100.0 || o["contingent"] != false {
+		t.Errorf("json: got %v, want the buy filled at 100", o)
+	}
+	if o := orders[2]; o["status"] != "pending" || o["filled_time"] != nil {
+		t.Errorf("json: got %v, want the limit order pending without a fill time", o)
+	}
+}
+
+func TestExportEquity(t *testing.T) {
+	res := exportRun(t)
+
+	var sb strings.Builder
+	if err := res.WriteEquityCSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	records := readCSV(t, sb.String())
+	if len(records) != len(res.Equity) {
+		t.Fatalf("csv: got %d points, want %d", len(records), len(res.Equity))
+	}
+	// The entry fee is charged on the second bar
+	if p := records[1]; p["time"] != "2024-01-02T00:00:00Z" || p["equity"] != "999" {
+		t.Errorf("csv: got %v, want 999 on 2024-01-02", p)
+	}
+	if dd, err := strconv.ParseFloat(records[1]["drawdown_pct"], 64); err != nil || math.Abs(dd+0.1) > 1e-9 {
+		t.Errorf("csv: got %s drawdown, want -0.1", records[1]["drawdown_pct"])
+	}
+
+	sb.Reset()
+	if err := res.WriteEquityJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+	var points []EquityPoint
+	if err := json.Unmarshal([]byte(sb.String()), &points); err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != len(res.Equity) {
+		t.Fatalf("json: got %d points, want %d", len(points), len(res.Equity))
+	}
+	for i, p := range points {
+		if !p.Time.Equal(res.Equity[i].Time) || p.Equity != res.Equity[i].Equity {
+			t.Errorf("json %d: got %+v, want %+v", i, p, res.Equity[i])
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package backtest
+
+import (
+	"context"
+	"time"
+)
+
+// DataFeed provides bars from a market data provider or any other source. See
+// the `data` package for adapters.
+type DataFeed interface {
+	// Bars returns bars keyed by symbol ready to be passed to `New`.
+	Bars(ctx context.Context, req BarsRequest) (map[string][]Bar, error)
+}
+
+// BarsRequest describes the bars requested from a `DataFeed`. Zero `Start` or `End`
+// leave the range unbounded on that side.
+type BarsRequest struct {
+	Symbols   []string
+	TimeFrame TimeFrame
+	Start     time.Time
+	End       time.Time
+}
+
+// Contains checks whether t is within the requested range (both ends inclusive).
+func (r BarsRequest) Contains(t time.Time) bool {
+	return (r.Start.IsZero() || !t.Before(r.Start)) && (r.End.IsZero() || !t.After(r.End))
+}
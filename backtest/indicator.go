@@ -0,0 +1,64 @@
+package backtest
+
+// Indicator is a stateful indicator computed one bar at a time. Unlike computing
+// an indicator over all prices on every bar, updating it is usually O(1) so runs
+// stay linear in the number of bars. See `Strategy.I`.
+type Indicator interface {
+	// Update advances the indicator with the next bar and returns its value.
+	Update(bar Bar) float64
+}
+
+// series is an indicator registered by a strategy along with its values so far.
+type series struct {
+	symbol    string
+	indicator Indicator
+	values    []float64
+}
+
+type seriesKey struct {
+	symbol string
+	name   string
+}
+
+// I returns the values of the indicator registered under name for the given symbol
+// aligned with its bars. The indicator is created with newFn the first time it's
+// requested, updated with the bars so far and from then on it's advanced on every
+// bar by `Backtest.Run`. Only closed bars are fed so on tick events it lags the
+// forming bar. The returned slice must not be modified.
+//
+//	sma := s.I("SPY", "sma", func() backtest.Indicator {
+//		return indicators.NewSMAStream(20, backtest.Close)
+//	})
+//	ma := sma[len(sma)-1]
+func (s Strategy) I(symbol, name string, newFn func() Indicator) []float64 {
+	key := seriesKey{symbol, name}
+	ser, ok := s.series[key]
+	if !ok {
+		ser = &series{symbol: symbol, indicator: newFn()}
+		s.series[key] = ser
+	}
+	s.update(ser)
+	return ser.values[:len(ser.values):len(ser.values)]
+}
+
+// advance updates every indicator registered by the strategy with the new bars.
+func (s Strategy) advance() {
+	for _, ser := range s.series {
+		s.update(ser)
+	}
+}
+
+// update feeds the indicator the closed bars it hasn't seen yet.
+func (s Strategy) update(ser *series) {
+	data, ok := s.Data[ser.symbol]
+	if !ok {
+		return
+	}
+	closed := len(data.bars)
+	if s.Tick != nil {
+		closed--
+	}
+	for _, bar := range data.bars[len(ser.values):max(closed, len(ser.values))] {
+		ser.values = append(ser.values, ser.indicator.Update(bar))
+	}
+}
@@ -0,0 +1,185 @@
+package backtest
+
+import (
+	"math"
+	"time"
+)
+
+// periodsPerYear returns the number of bars in a year used to annualize metrics.
+// The trading calendar is derived from bars: 252 trading days a year unless bars
+// fall on weekends (e.g. crypto) and, for intraday time frames, the average number
+// of bars per day.
+func (bt *Backtest) periodsPerYear() float64 {
+	if len(bt.symbols) == 0 {
+		return 0
+	}
+	bars := bt.data[bt.symbols[0]].bars
+	loc := bt.opts.session.location()
+
+	tradingDays := 252.0
+	days := make(map[time.Time]int)
+	for _, b := range bars {
+		t := b.Timestamp.In(loc)
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			tradingDays = 365
+		}
+		y, m, d := t.Date()
+		days[time.Date(y, m, d, 0, 0, 0, 0, loc)]++
+	}
+
+	tf := bt.opts.timeFrame
+	n := float64(max(tf.N, 1))
+	switch tf.Unit {
+	case Day:
+		return tradingDays / n
+	case Week:
+		return 52 / n
+	case Month:
+		return 12 / n
+	default:
+		return tradingDays * float64(len(bars)) / float64(len(days))
+	}
+}
+
+// returns returns the simple returns between consecutive equities.
+func returns(equities []float64) []float64 {
+	if len(equities) < 2 {
+		return nil
+	}
+	res := make([]float64, len(equities)-1)
+	for i := 1; i < len(equities); i++ {
+		if equities[i-1] != 0 {
+			res[i-1] = equities[i]/equities[i-1] - 1
+		}
+	}
+	return res
+}
+
+// benchmarkEquities returns the equity curve of an equally weighted buy & hold
+// portfolio of all symbols starting at 1 after the warm-up.
+func (bt *Backtest) benchmarkEquities() []float64 {
+	w := bt.opts.warmup
+	equities := make([]float64, bt.len()-w)
+	for _, data := range bt.data {
+		first := data.bars[w].Close
+		for i, b := range data.bars[w:] {
+			equities[i] += b.Close / first / float64(len(bt.data))
+		}
+	}
+	return equities
+}
+
+// stdDev returns the sample standard deviation of values.
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// downsideDev returns the deviation of negative values from 0.
+func downsideDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		if v < 0 {
+			sum += v * v
+		}
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// covariance returns the sample covariance of a and b which must be the same length.
+func covariance(a, b []float64) float64 {
+	if len(a) < 2 || len(a) != len(b) {
+		return 0
+	}
+	ma, mb := mean(a), mean(b)
+	var sum float64
+	for i := range a {
+		sum += (a[i] - ma) * (b[i] - mb)
+	}
+	return sum / float64(len(a)-1)
+}
+
+// annualize returns the annualized growth of a total return over n periods.
+func annualize(totalReturn, n, periodsPerYear float64) float64 {
+	if n <= 0 || periodsPerYear <= 0 || totalReturn <= -1 {
+		return 0
+	}
+	return math.Pow(1+totalReturn, periodsPerYear/n) - 1
+}
+
+// ratio returns a / b or 0 when b is 0 so that undefined ratios don't spread NaNs.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// riskMetrics are the annualized and risk adjusted metrics of an equity curve.
+type riskMetrics struct {
+	returnAnn     float64
+	volatilityAnn float64
+	sharpe        float64
+	sortino       float64
+	calmar        float64
+	alpha         float64
+	beta          float64
+}
+
+// riskMetrics computes the metrics of the equity curve against buy & hold. The
+// max drawdown is given as a negative fraction e.g. -0.2.
+func (bt *Backtest) riskMetrics(equities []float64, maxDrawdown float64) riskMetrics {
+	var m riskMetrics
+	if len(equities) < 2 || equities[0] <= 0 {
+		return m
+	}
+	ppy := bt.periodsPerYear()
+	n := float64(len(equities) - 1)
+	rf := bt.opts.riskFreeRate
+	rets := returns(equities)
+
+	m.returnAnn = annualize(equities[len(equities)-1]/equities[0]-1, n, ppy)
+	m.volatilityAnn = stdDev(rets) * math.Sqrt(ppy)
+	m.sharpe = ratio(m.returnAnn-rf, m.volatilityAnn)
+	m.sortino = ratio(m.returnAnn-rf, downsideDev(rets)*math.Sqrt(ppy))
+	m.calmar = ratio(m.returnAnn, math.Abs(maxDrawdown))
+
+	benchmark := bt.benchmarkEquities()
+	brets := returns(benchmark)
+	m.beta = ratio(covariance(rets, brets), covariance(brets, brets))
+	bReturnAnn := annualize(benchmark[len(benchmark)-1]/benchmark[0]-1, n, ppy)
+	m.alpha = (m.returnAnn - rf) - m.beta*(bReturnAnn-rf)
+	return m
+}
+
+// sqn returns the system quality number of the trades' PnLs.
+func sqn(pnls []float64) float64 {
+	if len(pnls) == 0 {
+		return 0
+	}
+	return math.Sqrt(float64(len(pnls))) * ratio(mean(pnls), stdDev(pnls))
+}
+
+// kelly returns the fraction of capital to risk per trade according to the Kelly
+// criterion given the trades' PnLs.
+func kelly(pnls []float64) float64 {
+	wins := count(pnls, func(e float64) bool { return e > 0 })
+	losses := count(pnls, func(e float64) bool { return e < 0 })
+	if wins == 0 || losses == 0 {
+		return 0
+	}
+	winRate := float64(wins) / float64(len(pnls))
+	avgWin := sumFunc(pnls, func(e float64) bool { return e > 0 }) / float64(wins)
+	avgLoss := -sumFunc(pnls, func(e float64) bool { return e < 0 }) / float64(losses)
+	return winRate - (1-winRate)/(avgWin/avgLoss)
+}
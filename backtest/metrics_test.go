@@ -0,0 +1,299 @@
+package backtest
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dailyBars returns a bar per close on consecutive days opening at the previous
+// close.
+func dailyBars(closes ...float64) []Bar {
+	bars := make([]Bar, len(closes))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, c := range closes {
+		open := c
+		if i > 0 {
+			open = closes[i-1]
+		}
+		bars[i] = Bar{
+			Timestamp: start.AddDate(0, 0, i),
+			Open:      open,
+			High:      max(open, c),
+			Low:       min(open, c),
+			Close:     c,
+			Volume:    1000,
+		}
+	}
+	return bars
+}
+
+// runBars runs cb on bars of symbol "X" with $1000 and the given options.
+func runBars(t *testing.T, bars []Bar, cb func(s *Strategy), opts ...Option) *Result {
+	t.Helper()
+	bt, err := New(map[string][]Bar{"X": bars}, append([]Option{WithCash(1000)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := bt.Strategy("test", cb).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// buyOnce buys a single unit on the first bar and holds it.
+func buyOnce(s *Strategy) {
+	if len(s.Data["X"].Bars()) == 1 {
+		s.Buy("X", TradeOpts{Size: 1})
+	}
+}
+
+// assertFinite fails when any of the stats is NaN. Profit factor is the only one
+// allowed to be infinite.
+func assertFinite(t *testing.T, st Stats) {
+	t.Helper()
+	v := reflect.ValueOf(st)
+	for i := range v.NumField() {
+		f := v.Field(i)
+		if f.Kind() != reflect.Float64 {
+			continue
+		}
+		name := v.Type().Field(i).Name
+		if math.IsNaN(f.Float()) || (math.IsInf(f.Float(), 0) && name != "ProfitFactor") {
+			t.Errorf("%s: got %f", name, f.Float())
+		}
+	}
+}
+
+func TestStatsNoTrades(t *testing.T) {
+	res := runBars(t, dailyBars(100, 101, 99, 102, 100), func(s *Strategy) {})
+	st := res.Stats
+	assertFinite(t, st)
+
+	if st.Trades != 0 || st.OpenTrades != 0 {
+		t.Errorf("trades: got %d closed and %d open, want none", st.Trades, st.OpenTrades)
+	}
+	// Flat equity has no volatility nor drawdown to divide by
+	for name, got := range map[string]float64{
+		"return":       st.ReturnPct,
+		"sharpe":       st.SharpeRatio,
+		"sortino":      st.SortinoRatio,
+		"calmar":       st.CalmarRatio,
+		"max drawdown": st.MaxDrawdownPct,
+		"exposure":     st.ExposureTimePct,
+		"win rate":     st.WinRatePct,
+		"profit":       st.ProfitFactor,
+		"sqn":          st.SQN,
+		"kelly":        st.KellyCriterion,
+	} {
+		if got != 0 {
+			t.Errorf("%s: got %f, want 0", name, got)
+		}
+	}
+	if st.MaxDrawdownDuration != 0 {
+		t.Errorf("max drawdown duration: got %s, want 0", st.MaxDrawdownDuration)
+	}
+}
+
+func TestStatsOpenTrades(t *testing.T) {
+	res := runBars(t, dailyBars(100, 100, 104, 108, 112), buyOnce)
+	st := res.Stats
+	assertFinite(t, st)
+
+	if st.Trades != 0 || st.OpenTrades != 1 {
+		t.Errorf("trades: got %d closed and %d open, want 0 and 1", st.Trades, st.OpenTrades)
+	}
+	// Filled on the second bar's open and held until the end
+	if want := 4.0 / 5 * 100; math.Abs(st.ExposureTimePct-want) > 1e-9 {
+		t.Errorf("exposure: got %f, want %f", st.ExposureTimePct, want)
+	}
+	if want := 1012.0; math.Abs(st.EquityFinal-want) > 1e-9 {
+		t.Errorf("final equity: got %f, want %f", st.EquityFinal, want)
+	}
+	// Trade stats only account for closed trades
+	if st.WinRatePct != 0 || st.SQN != 0 || st.KellyCriterion != 0 {
+		t.Errorf("trade stats: got win rate %f, sqn %f and kelly %f, want 0", st.WinRatePct, st.SQN, st.KellyCriterion)
+	}
+}
+
+func TestStatsUnrecoveredDrawdown(t *testing.T) {
+	bars := dailyBars(100, 100, 120, 110, 100, 90)
+	res := runBars(t, bars, buyOnce)
+	st := res.Stats
+	assertFinite(t, st)
+
+	// Equity peaks on the third bar and never gets back to it
+	peak, final := 1020.0, 990.0
+	if want := bars[5].Timestamp.Sub(bars[2].Timestamp); st.MaxDrawdownDuration != want {
+		t.Errorf("max drawdown duration: got %s, want %s", st.MaxDrawdownDuration, want)
+	}
+	if st.AvgDrawdownDuration != st.MaxDrawdownDuration {
+		t.Errorf("avg drawdown duration: got %s, want %s", st.AvgDrawdownDuration, st.MaxDrawdownDuration)
+	}
+	if want := (final/peak - 1) * 100; math.Abs(st.MaxDrawdownPct-want) > 1e-9 || math.Abs(st.AvgDrawdownPct-want) > 1e-9 {
+		t.Errorf("drawdown: got max %f and avg %f, want %f", st.MaxDrawdownPct, st.AvgDrawdownPct, want)
+	}
+	if want := final - peak; math.Abs(st.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("max drawdown: got %f, want %f", st.MaxDrawdown, want)
+	}
+	if got := res.Equity[len(res.Equity)-1].DrawdownPct; math.Abs(got-st.MaxDrawdownPct) > 1e-9 {
+		t.Errorf("last drawdown: got %f, want %f", got, st.MaxDrawdownPct)
+	}
+}
+
+func TestTradeMetrics(t *testing.T) {
+	tests := []struct {
+		name  string
+		pnls  []float64
+		sqn   float64
+		kelly float64
+	}{
+		{"no trades", nil, 0, 0},
+		{"only wins", []float64{10, 20}, math.Sqrt(2) * 15 / math.Sqrt(50), 0},
+		{"only losses", []float64{-10, -10}, 0, 0},
+		{"constant pnl", []float64{5, 5, 5}, 0, 0},
+		{"wins and losses", []float64{10, 10, -5}, math.Sqrt(3) * 5 / math.Sqrt(75), 2.0/3 - (1.0/3)/2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqn(tt.pnls); math.Abs(got-tt.sqn) > 1e-9 {
+				t.Errorf("sqn: got %f, want %f", got, tt.sqn)
+			}
+			if got := kelly(tt.pnls); math.Abs(got-tt.kelly) > 1e-9 {
+				t.Errorf("kelly: got %f, want %f", got, tt.kelly)
+			}
+		})
+	}
+}
+
+func TestRatio(t *testing.T) {
+	if got := ratio(1, 0); got != 0 {
+		t.Errorf("got %f, want 0", got)
+	}
+	if got := ratio(1, 4); got != 0.25 {
+		t.Errorf("got %f, want 0.25", got)
+	}
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	// every returns n bars d apart from midnight on a Monday in New York
+	every := func(n int, d time.Duration) []Bar {
+		bars := make([]Bar, n)
+		start := time.Date(2024, 4, 1, 0, 0, 0, 0, NYSE.Location)
+		for i := range bars {
+			bars[i] = Bar{Timestamp: start.Add(time.Duration(i) * d), Open: 100, High: 100, Low: 100, Close: 100}
+		}
+		return bars
+	}
+	// sessions returns 5 minute bars within the NYSE session on the given days.
+	sessions := func(days ...int) []Bar {
+		var bars []Bar
+		for _, day := range days {
+			bars = append(bars, nyBars(nyTimes(day, 9, 30, 78)...)...)
+		}
+		return bars
+	}
+
+	tests := []struct {
+		name string
+		bars []Bar
+		tf   TimeFrame
+		want float64
+	}{
+		{"trading days", every(5, 24*time.Hour), TimeFrame{}, 252},
+		{"calendar days", every(7, 24*time.Hour), TimeFrame{}, 365},
+		{"every other day", every(3, 48*time.Hour), TimeFrame{}, 126},
+		{"weeks", every(3, 7*24*time.Hour), NewTimeFrame(1, Week), 52},
+		{"months", every(3, 30*24*time.Hour), NewTimeFrame(1, Month), 12},
+		{"quarters", every(3, 90*24*time.Hour), NewTimeFrame(3, Month), 4},
+		// 78 bars of 5 minutes a day from 9:30 to 16:00
+		{"intraday", sessions(4, 5, 6), TimeFrame{}, 252 * 78},
+		// Hourly bars around the clock including weekends
+		{"around the clock", every(24*7, time.Hour), TimeFrame{}, 365 * 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithSession(NYSE)}
+			if tt.tf != (TimeFrame{}) {
+				opts = append(opts, WithTimeFrame(tt.tf))
+			}
+			bt, err := New(map[string][]Bar{"X": tt.bars}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bt.periodsPerYear(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRiskMetrics(t *testing.T) {
+	// Monthly bars so that 4 returns annualize with a power of 12 / 4. Buy & hold
+	// returns are half of the strategy's.
+	closes := []float64{100, 105, 99.75, 104.7375, 109.974375}
+	bars := make([]Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = Bar{Timestamp: time.Date(2024, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC), Open: c, High: c, Low: c, Close: c}
+	}
+	bt, err := New(map[string][]Bar{"X": bars}, WithTimeFrame(NewTimeFrame(1, Month)), WithRiskFreeRate(0.02))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Returns of 10%, -10%, 10% and 10% have a mean of 5% and a sample standard
+	// deviation of 10%. The downside deviation is sqrt(0.1² / 4) = 5%.
+	equities := []float64{100, 110, 99, 108.9, 119.79}
+	got := bt.riskMetrics(equities, -0.1)
+
+	returnAnn := math.Pow(1.1979, 3) - 1
+	benchmarkAnn := math.Pow(1.09974375, 3) - 1
+	want := riskMetrics{
+		returnAnn:     returnAnn,
+		volatilityAnn: 0.1 * math.Sqrt(12),
+		sharpe:        (returnAnn - 0.02) / (0.1 * math.Sqrt(12)),
+		sortino:       (returnAnn - 0.02) / (0.05 * math.Sqrt(12)),
+		calmar:        returnAnn / 0.1,
+		beta:          2,
+		alpha:         (returnAnn - 0.02) - 2*(benchmarkAnn-0.02),
+	}
+	for name, v := range map[string][2]float64{
+		"return":     {got.returnAnn, want.returnAnn},
+		"volatility": {got.volatilityAnn, want.volatilityAnn},
+		"sharpe":     {got.sharpe, want.sharpe},
+		"sortino":    {got.sortino, want.sortino},
+		"calmar":     {got.calmar, want.calmar},
+		"beta":       {got.beta, want.beta},
+		"alpha":      {got.alpha, want.alpha},
+	} {
+		if math.Abs(v[0]-v[1]) > 1e-9 {
+			t.Errorf("%s: got %f, want %f", name, v[0], v[1])
+		}
+	}
+}
+
+// TestRiskMetricsBuyAndHold checks holding the only symbol with all of the cash
+// tracks the benchmark.
+func TestRiskMetricsBuyAndHold(t *testing.T) {
+	// 10 units bought at the first close of 100 with $1000
+	res := runBars(t, dailyBars(100, 100, 104, 98, 103), func(s *Strategy) {
+		if len(s.Data["X"].Bars()) == 1 {
+			s.Buy("X", TradeOpts{Size: 10})
+		}
+	})
+	st := res.Stats
+	if math.Abs(st.Beta-1) > 1e-9 || math.Abs(st.AlphaPct) > 1e-9 {
+		t.Errorf("got beta %f and alpha %f, want 1 and 0", st.Beta, st.AlphaPct)
+	}
+	// 3% over 4 trading days
+	returnAnn := math.Pow(1.03, 252.0/4) - 1
+	if want := returnAnn * 100; math.Abs(st.ReturnAnnPct-want) > 1e-6 {
+		t.Errorf("annual return: got %f, want %f", st.ReturnAnnPct, want)
+	}
+	// The drawdown from 1040 to 980
+	if want := returnAnn / (60.0 / 1040); math.Abs(st.CalmarRatio-want) > 1e-9 {
+		t.Errorf("calmar: got %f, want %f", st.CalmarRatio, want)
+	}
+}
@@ -0,0 +1,255 @@
+package backtest
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Params are the parameters of a strategy for a single run e.g. {"period": 14}.
+type Params map[string]float64
+
+// Int returns the parameter rounded to the nearest integer e.g. periods.
+func (p Params) Int(name string) int {
+	return int(math.Round(p[name]))
+}
+
+// String returns the parameters sorted by name e.g. "fast=10 slow=30".
+func (p Params) String() string {
+	var s []string
+	for _, name := range symbols(p) {
+		s = append(s, name+"="+strconv.FormatFloat(p[name], 'f', -1, 64))
+	}
+	return strings.Join(s, " ")
+}
+
+// Grid maps parameter names to the values to try. Every combination of values is
+// run once.
+type Grid map[string][]float64
+
+// combinations returns the cartesian product of the grid's values sorted by name.
+func (g Grid) combinations() []Params {
+	combos := []Params{{}}
+	for _, name := range symbols(g) {
+		var next []Params
+		for _, p := range combos {
+			for _, v := range g[name] {
+				c := make(Params, len(p)+1)
+				for k, v := range p {
+					c[k] = v
+				}
+				c[name] = v
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// Range returns values from start to end, both inclusive, incremented by step
+// e.g. Range(10, 30, 10) returns [10 20 30].
+func Range(start, end, step float64) []float64 {
+	if step <= 0 {
+		return nil
+	}
+	var values []float64
+	// Values are computed from start instead of accumulated to avoid drifting
+	for i := 0; ; i++ {
+		v := start + float64(i)*step
+		if v > end+step*1e-9 {
+			break
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// Metric scores the result of a run. The higher the better.
+type Metric func(r *Result) float64
+
+// Metrics to rank runs by.
+var (
+	MetricReturn       Metric = func(r *Result) float64 { return r.Stats.ReturnPct }
+	MetricProfitFactor Metric = func(r *Result) float64 { return r.Stats.ProfitFactor }
+	// MetricMaxDrawdown favors the smallest drawdown as drawdowns are negative.
+	MetricMaxDrawdown Metric = func(r *Result) float64 { return r.Stats.MaxDrawdownPct }
+	MetricSharpe      Metric = func(r *Result) float64 { return r.Stats.SharpeRatio }
+	MetricSortino     Metric = func(r *Result) float64 { return r.Stats.SortinoRatio }
+	MetricCalmar      Metric = func(r *Result) float64 { return r.Stats.CalmarRatio }
+	MetricSQN         Metric = func(r *Result) float64 { return r.Stats.SQN }
+)
+
+// Metrics maps names to metrics for lookups e.g. from the command line.
+var Metrics = map[string]Metric{
+	"return":        MetricReturn,
+	"profit_factor": MetricProfitFactor,
+	"max_drawdown":  MetricMaxDrawdown,
+	"sharpe":        MetricSharpe,
+	"sortino":       MetricSortino,
+	"calmar":        MetricCalmar,
+	"sqn":           MetricSQN,
+}
+
+// Trial is a single run of an optimization.
+type Trial struct {
+	Params Params
+	Result *Result
+	// Score is the result's metric.
+	Score float64
+}
+
+type optimizeOpts struct {
+	// Options of every backtest.
+	options []Option
+	// Metric runs are ranked by. Defaults to return.
+	metric Metric
+	// Number of backtests run concurrently. Defaults to the number of CPUs.
+	workers int
+	// Constraints parameters must satisfy to be run.
+	constraints []func(p Params) bool
+	// Filters results must pass to be ranked.
+	filters []func(r *Result) bool
+}
+
+// OptimizeOption configures an optimization. See `Optimize`.
+type OptimizeOption func(o *optimizeOpts) error
+
+// WithOptions sets the options every backtest is created with.
+func WithOptions(opts ...Option) OptimizeOption {
+	return func(o *optimizeOpts) error {
+		o.options = append(o.options, opts...)
+		return nil
+	}
+}
+
+// WithMetric sets the metric runs are ranked by.
+func WithMetric(m Metric) OptimizeOption {
+	return func(o *optimizeOpts) error {
+		if m == nil {
+			return fmt.Errorf("%w: metric can't be nil", ErrInvalidOption)
+		}
+		o.metric = m
+		return nil
+	}
+}
+
+// WithWorkers sets the number of backtests run concurrently. Must be greater than 0.
+func WithWorkers(n int) OptimizeOption {
+	return func(o *optimizeOpts) error {
+		if n <= 0 {
+			return fmt.Errorf("%w: workers (%d) must be greater than 0", ErrInvalidOption, n)
+		}
+		o.workers = n
+		return nil
+	}
+}
+
+// WithConstraint skips combinations of parameters not satisfying fn e.g.
+//
+//	WithConstraint(func(p Params) bool { return p["fast"] < p["slow"] })
+func WithConstraint(fn func(p Params) bool) OptimizeOption {
+	return func(o *optimizeOpts) error {
+		o.constraints = append(o.constraints, fn)
+		return nil
+	}
+}
+
+// WithFilter leaves out of the ranking results not passing fn e.g.
+//
+//	WithFilter(func(r *Result) bool { return r.Stats.Trades >= 10 })
+func WithFilter(fn func(r *Result) bool) OptimizeOption {
+	return func(o *optimizeOpts) error {
+		o.filters = append(o.filters, fn)
+		return nil
+	}
+}
+
+// Optimize runs a fresh backtest on bars for every combination of parameters in the
+// grid and returns the trials ranked by metric, best first. `strategy` builds the
+// strategy for the given parameters e.g.
+//
+//	trials, err := Optimize(bars, Grid{"period": Range(10, 50, 5)}, func(p Params) func(s *Strategy) {
+//		return strategies.CloseOverSMA(p.Int("period"))
+//	}, WithMetric(MetricProfitFactor))
+//
+// Runs failing e.g. with `ErrAccountBlown` are left out of the ranking. An error is
+// returned when options are invalid or every run fails, and `ErrNoTrials` when
+// constraints or filters leave nothing to rank so that there's always a best trial.
+func Optimize(bars map[string][]Bar, grid Grid, strategy func(p Params) func(s *Strategy), opts ...OptimizeOption) ([]*Trial, error) {
+	o := optimizeOpts{metric: MetricReturn, workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	var combos []Params
+	for _, p := range grid.combinations() {
+		if !slices.ContainsFunc(o.constraints, func(fn func(p Params) bool) bool { return !fn(p) }) {
+			combos = append(combos, p)
+		}
+	}
+	if len(combos) == 0 {
+		return nil, fmt.Errorf("%w: no combination of parameters to run", ErrNoTrials)
+	}
+
+	trials := make([]*Trial, len(combos))
+	errs := make([]error, len(combos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(o.workers, len(combos)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				trials[i], errs[i] = run(bars, combos[i], strategy, o)
+			}
+		}()
+	}
+	for i := range combos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var ranked []*Trial
+	for i, t := range trials {
+		if errs[i] != nil {
+			continue
+		}
+		if slices.ContainsFunc(o.filters, func(fn func(r *Result) bool) bool { return !fn(t.Result) }) {
+			continue
+		}
+		ranked = append(ranked, t)
+	}
+	if len(ranked) == 0 {
+		if !slices.Contains(errs, nil) {
+			return nil, fmt.Errorf("every run failed, first error: %w", errs[0])
+		}
+		return nil, fmt.Errorf("%w: no run passed the filters", ErrNoTrials)
+	}
+	// Stable so that ties keep the grid's order
+	slices.SortStableFunc(ranked, func(a, b *Trial) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranked, nil
+}
+
+// run runs a single backtest with the given parameters.
+func run(bars map[string][]Bar, p Params, strategy func(p Params) func(s *Strategy), o optimizeOpts) (*Trial, error) {
+	bt, err := New(bars, o.options...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := bt.Strategy(cmp.Or(p.String(), "optimize"), strategy(p)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return &Trial{Params: p, Result: res, Score: o.metric(res)}, nil
+}
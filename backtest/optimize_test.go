@@ -0,0 +1,81 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+)
+
+// buyEvery buys a single unit every `period` bars.
+func buyEvery(p Params) func(s *Strategy) {
+	return func(s *Strategy) {
+		if len(s.Data["X"].Bars())%p.Int("period") == 0 {
+			s.Buy("X", TradeOpts{Size: 1})
+		}
+	}
+}
+
+func TestOptimizeNoTrials(t *testing.T) {
+	bars := map[string][]Bar{"X": dailyBars(100, 101, 102, 103, 104, 105, 106, 107)}
+	grid := Grid{"period": {2, 3}}
+
+	tests := []struct {
+		name string
+		opt  OptimizeOption
+	}{
+		{"constraints", WithConstraint(func(p Params) bool { return p["period"] > 5 })},
+		{"filters", WithFilter(func(r *Result) bool { return r.Stats.Trades > 100 })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trials, err := Optimize(bars, grid, buyEvery, tt.opt)
+			if !errors.Is(err, ErrNoTrials) {
+				t.Errorf("got %d trials and %v, want %v", len(trials), err, ErrNoTrials)
+			}
+			_, err = WalkForward(bars, Windows{InSample: 4, OutOfSample: 2}, grid, buyEvery, tt.opt)
+			if !errors.Is(err, ErrNoTrials) {
+				t.Errorf("walk forward: got %v, want %v", err, ErrNoTrials)
+			}
+		})
+	}
+
+	trials, err := Optimize(bars, grid, buyEvery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trials) != 2 {
+		t.Errorf("got %d trials, want 2", len(trials))
+	}
+}
+
+func TestWalkForwardWarmup(t *testing.T) {
+	bars := map[string][]Bar{"X": dailyBars(100, 101, 102, 103, 104, 105, 106, 107, 108, 109)}
+	w := Windows{InSample: 4, OutOfSample: 3}
+	// Only trades once it has seen more bars than an in-sample window holds
+	strategy := func(p Params) func(s *Strategy) {
+		return func(s *Strategy) {
+			if len(s.Data["X"].Bars()) == w.InSample+1 {
+				s.Buy("X", TradeOpts{Size: 1})
+			}
+		}
+	}
+
+	res, err := WalkForward(bars, w, Grid{"period": {1}}, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Windows) != 2 {
+		t.Fatalf("got %d windows, want 2", len(res.Windows))
+	}
+	for k, win := range res.Windows {
+		oos := win.OutOfSample
+		if oos.Stats.OpenTrades != 1 {
+			t.Errorf("window %d: got %d open trades, want 1 as out-of-sample runs are warmed up", k, oos.Stats.OpenTrades)
+		}
+		if want := bars["X"][w.InSample+k*w.OutOfSample].Timestamp; !oos.Stats.Start.Equal(want) {
+			t.Errorf("window %d: got start %s, want %s", k, oos.Stats.Start, want)
+		}
+	}
+	if len(res.Equity) != 6 {
+		t.Errorf("got %d stitched equity points, want 6", len(res.Equity))
+	}
+}
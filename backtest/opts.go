@@ -0,0 +1,257 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+)
+
+type Opts struct {
+	// This is our starting capital. Defaults to 100,000.00.
+	cash float64
+	// Value between 0 and 1 sets default order size based on perc of capital. Defaults to 3%.
+	orderSize float64
+	// Value between 0 and 1 sets initial margin where leverage = 1 / margin.
+	// Defaults to 1 (no leverage).
+	margin float64
+	// Value between 0 and `margin` sets the equity required to keep positions open
+	// before getting a margin call. Defaults to 0.
+	maintenanceMargin float64
+	// Commission charged on every fill. Defaults to no commission.
+	commission CommissionModel
+	// Slippage applied to market and stop fills. Defaults to no slippage.
+	slippage SlippageModel
+	// Enter trade on bar close else default to next bar's open
+	tradeOnClose bool
+	// When true it'll keep only one trade open at a time
+	exclusiveOrder bool
+	// When set to true order size will be treated as `fractional` instead of
+	// `notional` trade. E.g. 0.50 of a shared priced at $200 will create a trade of $100.
+	fractionable bool
+	// Time frame of bars. Defaults to the smallest gap between bars.
+	timeFrame TimeFrame
+	// Session bars are resampled within. Defaults to the whole day in UTC.
+	session Session
+	// Higher time frames bars are resampled to for multi-timeframe access.
+	resample []TimeFrame
+	// Annual risk free rate used by risk adjusted metrics e.g. Sharpe. Defaults to 0.
+	riskFreeRate float64
+	// Ticks keyed by symbol used to fill orders within bars. Defaults to none.
+	ticks map[string][]Tick
+	// Number of bars at the start only used to warm up strategies. Defaults to none.
+	warmup int
+}
+
+// Option configures a backtest. See `New`.
+type Option func(o *Opts) error
+
+func defaultOpts() Opts {
+	return Opts{
+		cash:      100000.00,
+		orderSize: 0.03,
+		margin:    1,
+	}
+}
+
+// validate checks options that depend on each other once all of them are applied.
+func (o Opts) validate() error {
+	if o.maintenanceMargin > o.margin {
+		return fmt.Errorf("%w: maintenance margin (%f) can't be greater than margin (%f)", ErrInvalidOption, o.maintenanceMargin, o.margin)
+	}
+	return nil
+}
+
+// WithCash sets the starting capital. Must be greater than 0.
+func WithCash(cash float64) Option {
+	return func(o *Opts) error {
+		if cash <= 0 {
+			return fmt.Errorf("%w: cash (%f) must be greater than 0", ErrInvalidOption, cash)
+		}
+		o.cash = cash
+		return nil
+	}
+}
+
+// WithOrderSize sets the default order size as a percentage of capital in (0, 1].
+func WithOrderSize(size float64) Option {
+	return func(o *Opts) error {
+		if size <= 0 || size > 1 {
+			return fmt.Errorf("%w: order size (%f) must be in (0, 1]", ErrInvalidOption, size)
+		}
+		o.orderSize = size
+		return nil
+	}
+}
+
+// WithMargin sets the required margin in (0, 1] e.g. 0.5 for 2:1 leverage.
+func WithMargin(margin float64) Option {
+	return func(o *Opts) error {
+		if margin <= 0 || margin > 1 {
+			return fmt.Errorf("%w: margin (%f) must be in (0, 1]", ErrInvalidOption, margin)
+		}
+		o.margin = margin
+		return nil
+	}
+}
+
+// WithMaintenanceMargin sets the maintenance margin in [0, 1]. Positions are
+// liquidated once equity drops below this percentage of their market value.
+// It can't be greater than the initial margin.
+func WithMaintenanceMargin(margin float64) Option {
+	return func(o *Opts) error {
+		if margin < 0 || margin > 1 {
+			return fmt.Errorf("%w: maintenance margin (%f) must be in [0, 1]", ErrInvalidOption, margin)
+		}
+		o.maintenanceMargin = margin
+		return nil
+	}
+}
+
+// WithCommission sets the commission model charged on every fill e.g.
+//
+//	WithCommission(PercentCommission(0.001))
+//	WithCommission(PerShareCommission{Rate: 0.005, Min: 1, Max: 10})
+func WithCommission(model CommissionModel) Option {
+	return func(o *Opts) error {
+		if model == nil {
+			return fmt.Errorf("%w: commission model can't be nil", ErrInvalidOption)
+		}
+		if pct, ok := model.(PercentCommission); ok && (pct < 0 || pct >= 1) {
+			return fmt.Errorf("%w: commission (%f) must be in [0, 1)", ErrInvalidOption, float64(pct))
+		}
+		o.commission = model
+		return nil
+	}
+}
+
+// WithSlippage sets the slippage model applied to market and stop fills e.g.
+//
+//	WithSlippage(FixedSlippage{Ticks: 1, TickSize: 0.01})
+//	WithSlippage(VolumeSlippage{PriceImpact: 0.1})
+func WithSlippage(model SlippageModel) Option {
+	return func(o *Opts) error {
+		if model == nil {
+			return fmt.Errorf("%w: slippage model can't be nil", ErrInvalidOption)
+		}
+		o.slippage = model
+		return nil
+	}
+}
+
+// WithTradeOnClose fills market orders at the close of the bar they were placed
+// on instead of the next bar's open, and records the fill at that bar. Limit and
+// stop orders aren't affected.
+func WithTradeOnClose(enabled bool) Option {
+	return func(o *Opts) error {
+		o.tradeOnClose = enabled
+		return nil
+	}
+}
+
+// WithExclusiveOrder keeps only one trade open at a time. Placing a new order
+// cancels pending orders and closes open trades.
+func WithExclusiveOrder(enabled bool) Option {
+	return func(o *Opts) error {
+		o.exclusiveOrder = enabled
+		return nil
+	}
+}
+
+// WithTimeFrame sets the time frame of the bars passed to `New`. It's inferred
+// from the smallest gap between bars when not set.
+func WithTimeFrame(tf TimeFrame) Option {
+	return func(o *Opts) error {
+		if !tf.valid() {
+			return fmt.Errorf("%w: invalid time frame %s", ErrInvalidOption, tf)
+		}
+		o.timeFrame = tf
+		return nil
+	}
+}
+
+// WithSession sets the trading session bars are resampled within e.g. `NYSE`.
+// Open and close must be within the day and open before close unless both are 0
+// (whole day).
+func WithSession(s Session) Option {
+	return func(o *Opts) error {
+		if s.Open < 0 || s.Close > 24*time.Hour || (s.Open != 0 || s.Close != 0) && s.Open >= s.Close {
+			return fmt.Errorf("%w: session must open (%s) before it closes (%s) within the day", ErrInvalidOption, s.Open, s.Close)
+		}
+		o.session = s
+		return nil
+	}
+}
+
+// WithResample makes bars resampled to the given higher time frames available to
+// strategies through `Data.Resampled`.
+func WithResample(tfs ...TimeFrame) Option {
+	return func(o *Opts) error {
+		for _, tf := range tfs {
+			if !tf.valid() {
+				return fmt.Errorf("%w: invalid time frame %s", ErrInvalidOption, tf)
+			}
+		}
+		o.resample = append(o.resample, tfs...)
+		return nil
+	}
+}
+
+// WithRiskFreeRate sets the annual risk free rate in [0, 1) used by risk adjusted
+// metrics e.g. 0.04 for 4%.
+func WithRiskFreeRate(rate float64) Option {
+	return func(o *Opts) error {
+		if rate < 0 || rate >= 1 {
+			return fmt.Errorf("%w: risk free rate (%f) must be in [0, 1)", ErrInvalidOption, rate)
+		}
+		o.riskFreeRate = rate
+		return nil
+	}
+}
+
+// WithTicks provides trade and/or quote ticks keyed by symbol. Orders of a symbol
+// are filled tick by tick within bars having ticks so that stops, limits and legs
+// are hit in the order prices were reached. Quotes fill buys at the ask and sells
+// at the bid so quotes must have both sides. `WithTradeOnClose` doesn't apply to
+// ticks.
+func WithTicks(ticks map[string][]Tick) Option {
+	return func(o *Opts) error {
+		for symbol, ts := range ticks {
+			for _, t := range ts {
+				if t.Kind != TradeTick && t.Kind != QuoteTick {
+					return fmt.Errorf("%w: %s tick at %s has unknown kind %q", ErrInvalidOption, symbol, t.Timestamp, t.Kind)
+				}
+				if t.Kind == TradeTick && t.Price <= 0 {
+					return fmt.Errorf("%w: %s trade at %s has no price", ErrInvalidPrice, symbol, t.Timestamp)
+				}
+				if t.Kind == QuoteTick && (t.Bid <= 0 || t.Ask <= 0) {
+					return fmt.Errorf("%w: %s quote at %s needs both a bid (%f) and an ask (%f)", ErrInvalidPrice, symbol, t.Timestamp, t.Bid, t.Ask)
+				}
+			}
+		}
+		o.ticks = ticks
+		return nil
+	}
+}
+
+// WithFractionable treats order sizes as fractional units instead of a percentage
+// of capital.
+func WithFractionable(enabled bool) Option {
+	return func(o *Opts) error {
+		o.fractionable = enabled
+		return nil
+	}
+}
+
+// WithWarmup sets the first n bars passed to `New` aside to warm up strategies.
+// Strategies are first called on the bar right after them with the warm-up bars
+// already in their data so that indicators are ready to trade. Results, i.e. the
+// equity curve and stats, start after the warm-up while trade and order bar
+// indexes still count it.
+func WithWarmup(n int) Option {
+	return func(o *Opts) error {
+		if n < 0 {
+			return fmt.Errorf("%w: warm-up (%d) can't be negative", ErrInvalidOption, n)
+		}
+		o.warmup = n
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		ok   bool
+	}{
+		{"defaults", nil, true},
+		{"cash", []Option{WithCash(500)}, true},
+		{"zero cash", []Option{WithCash(0)}, false},
+		{"negative cash", []Option{WithCash(-1)}, false},
+		{"order size", []Option{WithOrderSize(1)}, true},
+		{"zero order size", []Option{WithOrderSize(0)}, false},
+		{"order size above 1", []Option{WithOrderSize(1.5)}, false},
+		{"margin", []Option{WithMargin(0.5)}, true},
+		{"zero margin", []Option{WithMargin(0)}, false},
+		{"margin above 1", []Option{WithMargin(2)}, false},
+		{"maintenance margin", []Option{WithMargin(0.5), WithMaintenanceMargin(0.25)}, true},
+		{"negative maintenance margin", []Option{WithMaintenanceMargin(-0.1)}, false},
+		{"maintenance above margin", []Option{WithMargin(0.5), WithMaintenanceMargin(0.6)}, false},
+		// Checked once all options are applied so their order doesn't matter
+		{"maintenance before margin", []Option{WithMaintenanceMargin(0.6), WithMargin(0.5)}, false},
+		{"commission", []Option{WithCommission(PercentCommission(0.001))}, true},
+		{"nil commission", []Option{WithCommission(nil)}, false},
+		{"negative commission", []Option{WithCommission(PercentCommission(-0.001))}, false},
+		{"commission of 100%", []Option{WithCommission(PercentCommission(1))}, false},
+		{"slippage", []Option{WithSlippage(PercentSlippage(0.001))}, true},
+		{"nil slippage", []Option{WithSlippage(nil)}, false},
+		{"time frame", []Option{WithTimeFrame(NewTimeFrame(5, Minute))}, true},
+		{"zero time frame", []Option{WithTimeFrame(TimeFrame{})}, false},
+		{"session", []Option{WithSession(NYSE)}, true},
+		{"whole day session", []Option{WithSession(Session{})}, true},
+		{"session closing before it opens", []Option{WithSession(Session{Open: 16 * time.Hour, Close: 9 * time.Hour})}, false},
+		{"session past the day", []Option{WithSession(Session{Open: time.Hour, Close: 25 * time.Hour})}, false},
+		{"resample", []Option{WithResample(NewTimeFrame(1, Hour))}, true},
+		{"invalid resample", []Option{WithResample(NewTimeFrame(1, Hour), NewTimeFrame(0, Day))}, false},
+		{"risk free rate", []Option{WithRiskFreeRate(0.04)}, true},
+		{"risk free rate of 100%", []Option{WithRiskFreeRate(1)}, false},
+		{"warm-up", []Option{WithWarmup(2)}, true},
+		{"negative warm-up", []Option{WithWarmup(-1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(map[string][]Bar{"X": dailyBars(100, 101)}, tt.opts...)
+			if tt.ok && err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("got %v, want %v", err, ErrInvalidOption)
+			}
+		})
+	}
+}
+
+func TestOptionsApplied(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 101)}, WithCash(500), WithOrderSize(0.5), WithMargin(0.25), WithTradeOnClose(true), WithExclusiveOrder(true), WithFractionable(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := bt.opts
+	if o.cash != 500 || o.orderSize != 0.5 || o.margin != 0.25 || !o.tradeOnClose || !o.exclusiveOrder || !o.fractionable {
+		t.Errorf("got %+v, want the options applied", o)
+	}
+
+	// Defaults are kept for options not given
+	bt, err = New(map[string][]Bar{"X": dailyBars(100, 101)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := bt.opts; o.cash != 100000 || o.orderSize != 0.03 || o.margin != 1 {
+		t.Errorf("got %+v, want the defaults", o)
+	}
+	// The time frame is inferred from the bars
+	if want := NewTimeFrame(1, Day); bt.opts.timeFrame != want {
+		t.Errorf("time frame: got %s, want %s", bt.opts.timeFrame, want)
+	}
+}
@@ -1,7 +1,8 @@
 package backtest
 
 import (
-	"log"
+	"fmt"
+	"time"
 )
 
 type OrderType string
@@ -12,17 +13,33 @@ const (
 	Stop   OrderType = "stop"
 )
 
+type OrderStatus string
+
+const (
+	Pending  OrderStatus = "pending"
+	Filled   OrderStatus = "filled"
+	Canceled OrderStatus = "canceled"
+	Rejected OrderStatus = "rejected"
+)
+
 type Order struct {
-	Id      string
-	Size    float64
-	Side    Side
-	Stop    float64
-	Limit   float64
-	SL      float64
-	TP      float64
-	trade   *Trade
-	broker  *broker
-	hitAtOt OrderType
+	Id         string
+	Symbol     string
+	Size       float64
+	Side       Side
+	Stop       float64
+	Limit      float64
+	SL         float64
+	TP         float64
+	Status     OrderStatus
+	CreatedBar int
+	// Fill details are set once the order is filled.
+	FilledBar  int
+	FillPrice  float64
+	FilledSize float64
+	trade      *Trade
+	broker     *broker
+	hitAtOt    OrderType
 }
 
 // indexOf returns index of order in queue else -1
@@ -36,24 +53,50 @@ func (o *Order) indexOf() int {
 }
 
 // remove deletes order from queue.
-func (o *Order) remove() {
+func (o *Order) remove() error {
 	i := o.indexOf()
 	if i < 0 {
-		log.Fatalf("No order found with id: %s\n", o.Id)
+		return fmt.Errorf("%w: no order found with id %s", ErrOrderNotFound, o.Id)
 	}
 	o.broker.orders = append(o.broker.orders[:i], o.broker.orders[i+1:]...)
+	return nil
 }
 
 // Cancel removes order from queue and itself from the parent trade's legs slice.
-func (o *Order) Cancel() {
+func (o *Order) Cancel() error {
 	if o.trade != nil {
-		for _, leg := range o.trade.legs {
+		for i, leg := range o.trade.legs {
 			if leg != nil && leg.Id == o.Id {
-				leg = nil
+				o.trade.legs[i] = nil
 			}
 		}
 	}
-	o.remove()
+	if err := o.remove(); err != nil {
+		return err
+	}
+	o.Status = Canceled
+	return nil
+}
+
+// fill records the order's fill details.
+func (o *Order) fill(size, price float64, barI int) {
+	o.Status = Filled
+	o.FilledSize = size
+	o.FillPrice = price
+	o.FilledBar = barI
+}
+
+// CreatedTime returns a `time.Time` when order was placed.
+func (o *Order) CreatedTime() time.Time {
+	return o.broker.data[o.Symbol].bars[o.CreatedBar].Timestamp
+}
+
+// FilledTime returns a `time.Time` when order was filled or zero time if it wasn't.
+func (o *Order) FilledTime() time.Time {
+	if o.FilledSize == 0 {
+		return time.Time{}
+	}
+	return o.broker.data[o.Symbol].bars[o.FilledBar].Timestamp
 }
 
 // IsLong checks if order.Side is `Long`.
@@ -1,14 +1,29 @@
 package backtest
 
+// Position groups the open trades of a symbol.
 type Position struct {
 	broker *broker
+	symbol string
 }
 
-// Position size in units of asset. Negative if position is short.
+// Symbol returns the symbol the position is held in.
+func (p *Position) Symbol() string {
+	return p.symbol
+}
+
+// Position size in units of asset. Negative if position is short. Trade sizes are
+// always positive so short trades are subtracted.
 func (p *Position) Size() float64 {
 	var sum float64
 	for _, t := range p.broker.trades {
-		sum += t.Size
+		if t.Symbol != p.symbol {
+			continue
+		}
+		if t.Side == Sell {
+			sum -= t.Size
+		} else {
+			sum += t.Size
+		}
 	}
 	return sum
 }
@@ -17,7 +32,9 @@ func (p *Position) Size() float64 {
 func (p *Position) Pnl() float64 {
 	var sum float64
 	for _, t := range p.broker.trades {
-		sum += t.Pnl()
+		if t.Symbol == p.symbol {
+			sum += t.Pnl()
+		}
 	}
 	return sum
 }
@@ -25,7 +42,9 @@ func (p *Position) Pnl() float64 {
 // Close portion of position by closing `portion` of each active trade. See `Trade.close`.
 func (p *Position) Close(portion float64) {
 	for _, t := range p.broker.trades {
-		t.Close()
+		if t.Symbol == p.symbol {
+			t.Close()
+		}
 	}
 
 }
@@ -0,0 +1,32 @@
+package backtest
+
+import "testing"
+
+func TestPositionSize(t *testing.T) {
+	tests := []struct {
+		name string
+		side Side
+		want float64
+	}{
+		{"long", Buy, 3},
+		{"short", Sell, -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got float64
+			runBars(t, dailyBars(100, 100, 100), func(s *Strategy) {
+				order := s.Buy
+				if tt.side == Sell {
+					order = s.Sell
+				}
+				if len(s.Data["X"].Bars()) == 1 {
+					order("X", TradeOpts{Size: 3})
+				}
+				got = s.Positions["X"].Size()
+			})
+			if got != tt.want {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
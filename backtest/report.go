@@ -0,0 +1,166 @@
+package backtest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+)
+
+var (
+	//go:embed report/report.html
+	reportHTML string
+	//go:embed report/report.js
+	reportJS string
+
+	reportTmpl = template.Must(template.New("report").Parse(reportHTML))
+)
+
+// Overlay is a series drawn over the candlesticks of a symbol e.g. an indicator.
+// Values are aligned with the symbol's bars and NaNs are left out.
+type Overlay struct {
+	Symbol string
+	Name   string
+	Values []float64
+}
+
+type reportOpts struct {
+	title    string
+	overlays []Overlay
+}
+
+// ReportOption configures an HTML report. See `Result.WriteHTML`.
+type ReportOption func(o *reportOpts) error
+
+// WithTitle sets the title of the report. Defaults to the result's name.
+func WithTitle(title string) ReportOption {
+	return func(o *reportOpts) error {
+		o.title = title
+		return nil
+	}
+}
+
+// WithOverlays draws the given series over the candlesticks of their symbol e.g.
+//
+//	WithOverlays(Overlay{Symbol: "SPY", Name: "SMA(14)", Values: indicators.SMA(14, closes)})
+func WithOverlays(overlays ...Overlay) ReportOption {
+	return func(o *reportOpts) error {
+		o.overlays = append(o.overlays, overlays...)
+		return nil
+	}
+}
+
+// reportData is what the report's script renders.
+type reportData struct {
+	Symbols  []string                `json:"symbols"`
+	Times    []int64                 `json:"times"`
+	Bars     map[string][][5]float64 `json:"bars"`
+	Overlays []reportOverlay         `json:"overlays"`
+	Trades   []reportTrade           `json:"trades"`
+	Equity   []float64               `json:"equity"`
+	Drawdown []float64               `json:"drawdown"`
+}
+
+type reportOverlay struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	// Values are pointers so that NaNs are encoded as null.
+	Values []*float64 `json:"values"`
+}
+
+type reportTrade struct {
+	Symbol     string  `json:"symbol"`
+	Side       Side    `json:"side"`
+	EntryBar   int     `json:"entryBar"`
+	ExitBar    int     `json:"exitBar"`
+	EntryPrice float64 `json:"entryPrice"`
+	ExitPrice  float64 `json:"exitPrice"`
+	Pnl        float64 `json:"pnl"`
+}
+
+type reportStats struct {
+	Name string
+	Rows [][2]string
+}
+
+// WriteHTML writes a self-contained HTML report to w with candlesticks of every
+// symbol along with trade entries and exits, the equity and drawdown curves and
+// the stats. Its script is embedded so it works offline.
+func (r *Result) WriteHTML(w io.Writer, opts ...ReportOption) error {
+	o := reportOpts{title: r.Name}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return err
+		}
+	}
+
+	data := reportData{
+		Symbols:  r.Symbols,
+		Times:    make([]int64, len(r.Equity)),
+		Bars:     make(map[string][][5]float64, len(r.Bars)),
+		Overlays: []reportOverlay{},
+		Trades:   []reportTrade{},
+		Equity:   make([]float64, len(r.Equity)),
+		Drawdown: make([]float64, len(r.Equity)),
+	}
+	for i, e := range r.Equity {
+		data.Times[i] = e.Time.UnixMilli()
+		data.Equity[i] = e.Equity
+		data.Drawdown[i] = e.DrawdownPct
+	}
+	for symbol, bars := range r.Bars {
+		ohlcv := make([][5]float64, len(bars))
+		for i, b := range bars {
+			ohlcv[i] = [5]float64{b.Open, b.High, b.Low, b.Close, b.Volume}
+		}
+		data.Bars[symbol] = ohlcv
+	}
+	for _, ov := range o.overlays {
+		if _, ok := r.Bars[ov.Symbol]; !ok {
+			return fmt.Errorf("%w: overlay %q of %q", ErrUnknownSymbol, ov.Name, ov.Symbol)
+		}
+		values := make([]*float64, len(ov.Values))
+		for i, v := range ov.Values {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				values[i] = &v
+			}
+		}
+		data.Overlays = append(data.Overlays, reportOverlay{Symbol: ov.Symbol, Name: ov.Name, Values: values})
+	}
+	for _, t := range r.Trades {
+		data.Trades = append(data.Trades, reportTrade{
+			Symbol:     t.Symbol,
+			Side:       t.Side,
+			EntryBar:   t.EntryBar - r.warmup,
+			ExitBar:    t.ExitBar - r.warmup,
+			EntryPrice: t.EntryPrice,
+			ExitPrice:  t.ExitPrice,
+			Pnl:        t.Pnl(),
+		})
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var stats []reportStats
+	for _, a := range r.Accounts {
+		stats = append(stats, reportStats{Name: a.Name, Rows: a.rows()})
+	}
+	stats = append(stats, reportStats{Name: r.Name, Rows: r.rows()})
+
+	return reportTmpl.Execute(w, struct {
+		Title  string
+		Stats  []reportStats
+		Data   template.JS
+		Script template.JS
+	}{
+		Title:  strings.TrimSpace(o.title),
+		Stats:  stats,
+		Data:   template.JS(b),
+		Script: template.JS(reportJS),
+	})
+}
@@ -0,0 +1,301 @@
+// Renders the charts of a backtest report from the global REPORT object. It has no
+// dependencies so reports work offline.
+(function () {
+  "use strict";
+
+  const UP = "#26a69a", DOWN = "#ef5350", LINE = "#2962ff", GRID = "#eaeef2", TEXT = "#656d76";
+  const PALETTE = ["#ff9800", "#9c27b0", "#00bcd4", "#795548", "#e91e63", "#607d8b"];
+  const AXIS = 64, PAD = 8;
+  const n = REPORT.times.length;
+  const view = { start: 0, end: n };
+  const panels = [];
+  const tooltip = document.getElementById("tooltip");
+  let hover = -1;
+
+  function fmtTime(ms) {
+    return new Date(ms).toISOString().replace("T", " ").replace(/:00\.000Z$|\.000Z$/, "");
+  }
+
+  function fmtNum(v) {
+    if (v === null || v === undefined || !isFinite(v)) return "-";
+    return Math.abs(v) >= 1000 ? v.toFixed(0) : v.toPrecision(6).replace(/\.?0+$/, "");
+  }
+
+  function addPanel(title, height, draw, range, describe) {
+    const el = document.createElement("div");
+    el.className = "panel";
+    const canvas = document.createElement("canvas");
+    canvas.style.height = height + "px";
+    const label = document.createElement("div");
+    label.className = "panel-title";
+    label.textContent = title;
+    el.appendChild(canvas);
+    el.appendChild(label);
+    document.getElementById("charts").appendChild(el);
+    const panel = { canvas, height, draw, range, describe };
+    panels.push(panel);
+    bind(panel);
+    return panel;
+  }
+
+  // Geometry shared by every panel so that bars line up vertically.
+  function geometry(panel) {
+    const width = panel.canvas.clientWidth - AXIS;
+    const step = width / (view.end - view.start);
+    const [lo, hi] = panel.range();
+    const span = hi - lo || 1;
+    const h = panel.height - 2 * PAD;
+    return {
+      width, step,
+      x: (i) => (i - view.start + 0.5) * step,
+      y: (v) => PAD + (hi - v) / span * h,
+      index: (px) => Math.floor(px / step) + view.start,
+      lo, hi,
+    };
+  }
+
+  function visibleRange(series) {
+    let lo = Infinity, hi = -Infinity;
+    for (const values of series) {
+      for (let i = view.start; i < view.end; i++) {
+        const v = values(i);
+        if (v === null || v === undefined || !isFinite(v)) continue;
+        lo = Math.min(lo, v);
+        hi = Math.max(hi, v);
+      }
+    }
+    if (!isFinite(lo)) return [0, 1];
+    const margin = (hi - lo) * 0.05 || Math.abs(hi) * 0.01 || 1;
+    return [lo - margin, hi + margin];
+  }
+
+  function render(panel) {
+    const ratio = window.devicePixelRatio || 1;
+    const canvas = panel.canvas;
+    canvas.width = canvas.clientWidth * ratio;
+    canvas.height = panel.height * ratio;
+    const ctx = canvas.getContext("2d");
+    ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+    ctx.clearRect(0, 0, canvas.clientWidth, panel.height);
+    const g = geometry(panel);
+
+    // Horizontal grid and value axis
+    ctx.font = "11px sans-serif";
+    ctx.fillStyle = TEXT;
+    ctx.strokeStyle = GRID;
+    ctx.lineWidth = 1;
+    for (let k = 0; !panel.bare && k <= 4; k++) {
+      const v = g.lo + (g.hi - g.lo) * k / 4;
+      const y = Math.round(g.y(v)) + 0.5;
+      ctx.beginPath();
+      ctx.moveTo(0, y);
+      ctx.lineTo(g.width, y);
+      ctx.stroke();
+      ctx.fillText(fmtNum(v), g.width + 4, y + 4);
+    }
+
+    ctx.save();
+    ctx.beginPath();
+    ctx.rect(0, 0, g.width, panel.height);
+    ctx.clip();
+    panel.draw(ctx, g);
+    if (hover >= view.start && hover < view.end) {
+      ctx.strokeStyle = "#8c959f";
+      ctx.setLineDash([4, 4]);
+      ctx.beginPath();
+      ctx.moveTo(Math.round(g.x(hover)) + 0.5, 0);
+      ctx.lineTo(Math.round(g.x(hover)) + 0.5, panel.height);
+      ctx.stroke();
+      ctx.setLineDash([]);
+    }
+    ctx.restore();
+  }
+
+  function renderAll() {
+    panels.forEach(render);
+  }
+
+  function line(ctx, g, values, color, width) {
+    ctx.strokeStyle = color;
+    ctx.lineWidth = width || 1.5;
+    ctx.beginPath();
+    let started = false;
+    for (let i = view.start; i < view.end; i++) {
+      const v = values(i);
+      if (v === null || v === undefined || !isFinite(v)) {
+        started = false;
+        continue;
+      }
+      if (started) ctx.lineTo(g.x(i), g.y(v));
+      else ctx.moveTo(g.x(i), g.y(v));
+      started = true;
+    }
+    ctx.stroke();
+  }
+
+  function candles(symbol) {
+    const bars = REPORT.bars[symbol];
+    const overlays = REPORT.overlays.filter((o) => o.symbol === symbol);
+    const trades = REPORT.trades.filter((t) => t.symbol === symbol);
+    return function (ctx, g) {
+      const w = Math.max(1, g.step * 0.7);
+      for (let i = view.start; i < view.end; i++) {
+        const [o, h, l, c] = bars[i];
+        const x = g.x(i);
+        ctx.strokeStyle = ctx.fillStyle = c >= o ? UP : DOWN;
+        ctx.beginPath();
+        ctx.moveTo(Math.round(x) + 0.5, g.y(h));
+        ctx.lineTo(Math.round(x) + 0.5, g.y(l));
+        ctx.stroke();
+        const top = g.y(Math.max(o, c));
+        ctx.fillRect(x - w / 2, top, w, Math.max(1, g.y(Math.min(o, c)) - top));
+      }
+      overlays.forEach((o, k) => line(ctx, g, (i) => o.values[i], PALETTE[k % PALETTE.length], 1.2));
+      for (const t of trades) {
+        if (t.exitBar < view.start || t.entryBar >= view.end) continue;
+        ctx.strokeStyle = t.pnl >= 0 ? UP : DOWN;
+        ctx.setLineDash([3, 3]);
+        ctx.beginPath();
+        ctx.moveTo(g.x(t.entryBar), g.y(t.entryPrice));
+        ctx.lineTo(g.x(t.exitBar), g.y(t.exitPrice));
+        ctx.stroke();
+        ctx.setLineDash([]);
+        marker(ctx, g.x(t.entryBar), g.y(t.entryPrice), t.side === "buy" ? "up" : "down");
+        marker(ctx, g.x(t.exitBar), g.y(t.exitPrice), "exit");
+      }
+    };
+  }
+
+  function marker(ctx, x, y, kind) {
+    const s = 6;
+    ctx.beginPath();
+    if (kind === "up") {
+      ctx.fillStyle = UP;
+      ctx.moveTo(x, y - s);
+      ctx.lineTo(x - s, y + s);
+      ctx.lineTo(x + s, y + s);
+    } else if (kind === "down") {
+      ctx.fillStyle = DOWN;
+      ctx.moveTo(x, y + s);
+      ctx.lineTo(x - s, y - s);
+      ctx.lineTo(x + s, y - s);
+    } else {
+      ctx.fillStyle = "#1f2328";
+      ctx.arc(x, y, s / 2 + 1, 0, 2 * Math.PI);
+    }
+    ctx.closePath();
+    ctx.fill();
+  }
+
+  // Zooming, panning and hovering are shared by every panel.
+  function bind(panel) {
+    const canvas = panel.canvas;
+    let drag = null;
+    canvas.addEventListener("wheel", (e) => {
+      e.preventDefault();
+      const g = geometry(panel);
+      const x = e.offsetX;
+      if (x > g.width) return;
+      const at = g.index(x);
+      const size = view.end - view.start;
+      const next = Math.min(n, Math.max(10, Math.round(size * (e.deltaY > 0 ? 1.2 : 1 / 1.2))));
+      const left = (at - view.start) / size;
+      view.start = Math.max(0, Math.round(at - left * next));
+      view.end = Math.min(n, view.start + next);
+      view.start = Math.max(0, view.end - next);
+      renderAll();
+    }, { passive: false });
+    canvas.addEventListener("mousedown", (e) => {
+      drag = { x: e.clientX, start: view.start, end: view.end };
+    });
+    window.addEventListener("mouseup", () => { drag = null; });
+    canvas.addEventListener("mousemove", (e) => {
+      const g = geometry(panel);
+      if (drag) {
+        const shift = Math.round((drag.x - e.clientX) / g.step);
+        const size = drag.end - drag.start;
+        view.start = Math.min(n - size, Math.max(0, drag.start + shift));
+        view.end = view.start + size;
+      }
+      hover = Math.min(view.end - 1, Math.max(view.start, g.index(e.offsetX)));
+      showTooltip(e.clientX, e.clientY);
+      renderAll();
+    });
+    canvas.addEventListener("mouseleave", () => {
+      hover = -1;
+      tooltip.style.display = "none";
+      renderAll();
+    });
+    canvas.addEventListener("dblclick", () => {
+      view.start = 0;
+      view.end = n;
+      renderAll();
+    });
+  }
+
+  function showTooltip(x, y) {
+    if (hover < 0) return;
+    const lines = [fmtTime(REPORT.times[hover])];
+    panels.forEach((p) => p.describe && lines.push(...p.describe(hover)));
+    tooltip.textContent = lines.join("\n");
+    tooltip.style.display = "block";
+    const left = x + 16 + tooltip.offsetWidth > window.innerWidth ? x - 16 - tooltip.offsetWidth : x + 16;
+    tooltip.style.left = left + "px";
+    tooltip.style.top = y + 16 + "px";
+  }
+
+  for (const symbol of REPORT.symbols) {
+    const bars = REPORT.bars[symbol];
+    const overlays = REPORT.overlays.filter((o) => o.symbol === symbol);
+    addPanel(symbol, 360, candles(symbol), () => visibleRange([
+      (i) => bars[i][1],
+      (i) => bars[i][2],
+      ...overlays.map((o) => (i) => o.values[i]),
+    ]), (i) => {
+      const [o, h, l, c, v] = bars[i];
+      const lines = [`${symbol} O ${fmtNum(o)} H ${fmtNum(h)} L ${fmtNum(l)} C ${fmtNum(c)} V ${fmtNum(v)}`];
+      overlays.forEach((ov) => lines.push(`  ${ov.name} ${fmtNum(ov.values[i])}`));
+      return lines;
+    });
+  }
+
+  addPanel("Equity", 180, (ctx, g) => line(ctx, g, (i) => REPORT.equity[i], LINE),
+    () => visibleRange([(i) => REPORT.equity[i]]),
+    (i) => [`Equity ${fmtNum(REPORT.equity[i])}`]);
+
+  addPanel("Drawdown %", 120, (ctx, g) => {
+    ctx.fillStyle = "rgba(239, 83, 80, .25)";
+    ctx.beginPath();
+    ctx.moveTo(g.x(view.start), g.y(0));
+    for (let i = view.start; i < view.end; i++) ctx.lineTo(g.x(i), g.y(REPORT.drawdown[i]));
+    ctx.lineTo(g.x(view.end - 1), g.y(0));
+    ctx.closePath();
+    ctx.fill();
+    line(ctx, g, (i) => REPORT.drawdown[i], DOWN, 1);
+  }, () => {
+    const [lo] = visibleRange([(i) => REPORT.drawdown[i]]);
+    return [Math.min(lo, -1), 0];
+  }, (i) => [`Drawdown ${fmtNum(REPORT.drawdown[i])}%`]);
+
+  // Time axis below the last panel
+  const axis = document.createElement("canvas");
+  axis.style.height = "20px";
+  document.getElementById("charts").appendChild(axis);
+  panels.push({
+    canvas: axis,
+    height: 20,
+    bare: true,
+    range: () => [0, 1],
+    describe: null,
+    draw: (ctx, g) => {
+      ctx.fillStyle = TEXT;
+      const every = Math.max(1, Math.ceil(130 / g.step));
+      for (let i = Math.ceil(view.start / every) * every; i < view.end; i += every) {
+        ctx.fillText(fmtTime(REPORT.times[i]), g.x(i) - 45, 14);
+      }
+    },
+  });
+
+  window.addEventListener("resize", renderAll);
+  renderAll();
+})();
@@ -0,0 +1,112 @@
+package backtest
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// reportJSON returns the data embedded in the report's script.
+func reportJSON(t *testing.T, html string) reportData {
+	t.Helper()
+	const prefix = "const REPORT = "
+	i := strings.Index(html, prefix)
+	if i < 0 {
+		t.Fatalf("got %q, want the report's data", html)
+	}
+	j := strings.Index(html[i:], ";</script>")
+	var data reportData
+	if err := json.Unmarshal([]byte(html[i+len(prefix):i+j]), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWriteHTML(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 100, 100, 110, 110)}, WithCash(1000), WithWarmup(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Called from the second bar on, buys at 100 and sells at 110
+	res, err := bt.Strategy("test", func(s *Strategy) {
+		switch len(s.Data["X"].Bars()) {
+		case 2:
+			s.Buy("X", TradeOpts{Size: 1})
+		case 4:
+			s.Sell("X", TradeOpts{Size: 1})
+		}
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	overlay := Overlay{Symbol: "X", Name: "SMA", Values: []float64{math.NaN(), 100, math.Inf(1), 105}}
+	if err := res.WriteHTML(&sb, WithTitle("<script>"), WithOverlays(overlay)); err != nil {
+		t.Fatal(err)
+	}
+	html := sb.String()
+
+	// The title is escaped and the script is embedded instead of loaded
+	if strings.Contains(html, "<title><script>") || !strings.Contains(html, "<title>&lt;script&gt;</title>") {
+		t.Error("got the title unescaped")
+	}
+	if strings.Contains(html, "src=") || !strings.Contains(html, reportJS[:100]) {
+		t.Error("got the script loaded from elsewhere, want it embedded")
+	}
+	for _, want := range []string{"<caption>test</caption>", "<td>Equity Final</td><td>$1010.000000</td>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("got no %q in the stats", want)
+		}
+	}
+
+	data := reportJSON(t, html)
+	if len(data.Times) != 4 || len(data.Equity) != 4 || len(data.Drawdown) != 4 || len(data.Bars["X"]) != 4 {
+		t.Fatalf("got %d times, %d equity points, %d drawdowns and %d bars, want 4 of each after the warm-up", len(data.Times), len(data.Equity), len(data.Drawdown), len(data.Bars["X"]))
+	}
+	if data.Times[0] != res.Equity[0].Time.UnixMilli() || data.Bars["X"][2] != [5]float64{100, 110, 100, 110, 1000} {
+		t.Errorf("got %d and %v, want the first time and the third bar after the warm-up", data.Times[0], data.Bars["X"][2])
+	}
+	// NaN and infinite values are left out of overlays
+	if ov := data.Overlays; len(ov) != 1 || ov[0].Values[0] != nil || ov[0].Values[2] != nil || *ov[0].Values[1] != 100 || *ov[0].Values[3] != 105 {
+		t.Errorf("got %+v, want values 1 and 3 only", ov)
+	}
+	// Trade bars are relative to the first bar after the warm-up
+	if tr := data.Trades; len(tr) != 1 || tr[0].EntryBar != 1 || tr[0].ExitBar != 3 || tr[0].Pnl != 10 {
+		t.Errorf("got %+v, want a trade from bar 1 to 3", tr)
+	}
+}
+
+func TestWriteHTMLAccounts(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 100, 110, 110)}, WithCash(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := bt.Strategy("a", roundTrip).Strategy("b", buyOnce).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := res.WriteHTML(&sb); err != nil {
+		t.Fatal(err)
+	}
+	// Stats of each account are followed by all of them combined
+	html := sb.String()
+	a, b, combined := strings.Index(html, "<caption>a</caption>"), strings.Index(html, "<caption>b</caption>"), strings.Index(html, "<caption>combined</caption>")
+	if a < 0 || b < a || combined < b {
+		t.Error("got no stats of a, b and combined in order")
+	}
+	if !strings.Contains(html, "<title>combined</title>") {
+		t.Error("got no title defaulting to the result's name")
+	}
+}
+
+func TestWriteHTMLUnknownOverlay(t *testing.T) {
+	res := runBars(t, dailyBars(100, 101), func(s *Strategy) {})
+	err := res.WriteHTML(&strings.Builder{}, WithOverlays(Overlay{Symbol: "Y", Name: "SMA"}))
+	if !errors.Is(err, ErrUnknownSymbol) {
+		t.Errorf("got %v, want %v", err, ErrUnknownSymbol)
+	}
+}
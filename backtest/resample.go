@@ -0,0 +1,210 @@
+package backtest
+
+import (
+	"time"
+	// Embeds the time zone database so that sessions like `NYSE` load on systems
+	// without one.
+	_ "time/tzdata"
+)
+
+// Session is the trading session bars belong to. Open and Close are offsets from
+// midnight in `Location` e.g. 9h30m and 16h for NYSE regular hours. A zero session
+// is the whole day in UTC.
+type Session struct {
+	Location *time.Location
+	Open     time.Duration
+	Close    time.Duration
+}
+
+// NYSE is the regular trading session of US equities.
+var NYSE = Session{
+	Location: mustLoadLocation("America/New_York"),
+	Open:     9*time.Hour + 30*time.Minute,
+	Close:    16 * time.Hour,
+}
+
+// mustLoadLocation loads the named location. It can only panic on invalid names as
+// the time zone database is embedded.
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+func (s Session) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// hasHours reports whether the session has trading hours instead of the whole day.
+// Sessions with hours are expected to trade on weekdays.
+func (s Session) hasHours() bool {
+	return s.Close > s.Open
+}
+
+// at returns the time at offset d on the given date in the session's location. It
+// goes through `time.Date` so that offsets are wall clock times on DST changes.
+func (s Session) at(year int, month time.Month, day int, d time.Duration) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, int(d), s.location())
+}
+
+// open returns the session's open on the date of t.
+func (s Session) open(t time.Time) time.Time {
+	y, m, d := t.In(s.location()).Date()
+	return s.at(y, m, d, s.Open)
+}
+
+// OpenOn returns the session's open on the date of t in the session's location, or
+// midnight when the session is the whole day.
+func (s Session) OpenOn(t time.Time) time.Time {
+	return s.open(t)
+}
+
+// close returns the session's close on the date of t, or the next midnight when
+// the session is the whole day.
+func (s Session) close(t time.Time) time.Time {
+	y, m, d := t.In(s.location()).Date()
+	if !s.hasHours() {
+		return s.at(y, m, d+1, 0)
+	}
+	return s.at(y, m, d, s.Close)
+}
+
+// Contains reports whether t is within the session's hours.
+func (s Session) Contains(t time.Time) bool {
+	if !s.hasHours() {
+		return true
+	}
+	return !t.Before(s.open(t)) && t.Before(s.close(t))
+}
+
+// bucket returns the start and end of the time frame's period t belongs to. Intraday
+// periods are anchored to the session's open and cut at its close. Days, weeks and
+// months end at the close of their last trading day.
+func (s Session) bucket(t time.Time, tf TimeFrame) (start, end time.Time) {
+	loc := s.location()
+	y, m, d := t.In(loc).Date()
+	n := max(tf.N, 1)
+	switch tf.Unit {
+	case Day:
+		days := epochDays(y, m, d)
+		days -= mod(days, n)
+		start = s.at(1970, time.January, 1+days, 0)
+		end = s.lastClose(start.AddDate(0, 0, n-1))
+	case Week:
+		// 1970-01-05 is the first Monday after the epoch
+		weeks := floorDiv(epochDays(y, m, d)-4, 7)
+		weeks -= mod(weeks, n)
+		start = s.at(1970, time.January, 5+weeks*7, 0)
+		end = s.lastClose(start.AddDate(0, 0, 7*n-1))
+	case Month:
+		months := y*12 + int(m) - 1
+		months -= mod(months, n)
+		start = s.at(months/12, time.Month(months%12+1), 1, 0)
+		end = s.lastClose(start.AddDate(0, n, -1))
+	default:
+		anchor := s.open(t)
+		if !s.hasHours() {
+			anchor = s.at(y, m, d, 0)
+		}
+		dur := tf.Duration()
+		start = anchor.Add(t.Sub(anchor) / dur * dur)
+		end = start.Add(dur)
+		if c := s.close(t); end.After(c) {
+			end = c
+		}
+	}
+	return start, end
+}
+
+// lastClose returns the close of the session on the given day or the closest weekday
+// before it for sessions with hours.
+func (s Session) lastClose(day time.Time) time.Time {
+	if s.hasHours() {
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, -1)
+		}
+	}
+	return s.close(day)
+}
+
+// epochDays returns the number of days since 1970-01-01 of the given date.
+func epochDays(y int, m time.Month, d int) int {
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// mod returns the non negative remainder of a / b.
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
+
+// floorDiv returns a / b rounded down.
+func floorDiv(a, b int) int {
+	return (a - mod(a, b)) / b
+}
+
+// Resample aggregates bars into bars of the given time frame within the session's
+// boundaries. Bars outside the session's hours are left out. Resampled bars are
+// timestamped at the start of their period e.g. midnight in the session's location
+// for days.
+func Resample(bars []Bar, tf TimeFrame, session Session) []Bar {
+	res, _ := resample(bars, tf, session)
+	return res
+}
+
+// resample aggregates bars like `Resample` and also returns the end of each period.
+func resample(bars []Bar, tf TimeFrame, session Session) ([]Bar, []time.Time) {
+	var res []Bar
+	var ends []time.Time
+	var notional float64
+	for _, b := range bars {
+		if !session.Contains(b.Timestamp) {
+			continue
+		}
+		start, end := session.bucket(b.Timestamp, tf)
+		if len(res) == 0 || !res[len(res)-1].Timestamp.Equal(start) {
+			res = append(res, Bar{Timestamp: start, Open: b.Open, High: b.High, Low: b.Low})
+			ends = append(ends, end)
+			notional = 0
+		}
+		r := &res[len(res)-1]
+		r.High = max(r.High, b.High)
+		r.Low = min(r.Low, b.Low)
+		r.Close = b.Close
+		r.Volume += b.Volume
+		r.TradeCount += b.TradeCount
+		notional += b.VWAP * b.Volume
+		if r.Volume > 0 {
+			r.VWAP = notional / r.Volume
+		}
+	}
+	return res, ends
+}
+
+// frame holds the bars of a higher time frame along with how many of them are
+// completed at each bar of the base time frame.
+type frame struct {
+	bars      []Bar
+	completed []int
+}
+
+// newFrame resamples bars into tf. A period is completed at a bar once the bar's
+// own end (its timestamp plus `base`) reaches the end of the period so that the
+// last bar of a period completes it without having to wait for the next one.
+func newFrame(bars []Bar, base, tf TimeFrame, session Session) *frame {
+	res, ends := resample(bars, tf, session)
+	f := &frame{bars: res, completed: make([]int, len(bars))}
+	j := 0
+	for i, b := range bars {
+		barEnd := b.Timestamp.Add(base.Duration())
+		for j < len(ends) && !ends[j].After(barEnd) {
+			j++
+		}
+		f.completed[i] = j
+	}
+	return f
+}
@@ -0,0 +1,157 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// nyBars returns a 5 minute bar per timestamp where bar i opens at 100 + i with a
+// range of 2 around it.
+func nyBars(ts ...time.Time) []Bar {
+	bars := make([]Bar, len(ts))
+	for i, t := range ts {
+		p := float64(100 + i)
+		bars[i] = Bar{Timestamp: t, Open: p, High: p + 1, Low: p - 1, Close: p + 0.5, Volume: float64(10 * (i + 1)), VWAP: p, TradeCount: 1}
+	}
+	return bars
+}
+
+// nyTimes returns n timestamps every 5 minutes from the given time in New York.
+func nyTimes(day, hour, min, n int) []time.Time {
+	ts := make([]time.Time, n)
+	for i := range ts {
+		ts[i] = time.Date(2024, 3, day, hour, min+5*i, 0, 0, NYSE.Location)
+	}
+	return ts
+}
+
+func TestResample(t *testing.T) {
+	at := func(day, hour, min int) time.Time { return time.Date(2024, 3, day, hour, min, 0, 0, NYSE.Location) }
+	// Monday the 4th from the open and the close, and the open of Tuesday
+	ts := append(nyTimes(4, 9, 30, 6), nyTimes(4, 15, 50, 2)...)
+	ts = append(ts, at(5, 9, 30))
+	bars := nyBars(ts...)
+
+	tests := []struct {
+		name  string
+		tf    TimeFrame
+		bars  []Bar
+		start []time.Time
+		end   []time.Time
+	}{
+		{
+			name:  "15 minutes",
+			tf:    NewTimeFrame(15, Minute),
+			bars:  bars,
+			start: []time.Time{at(4, 9, 30), at(4, 9, 45), at(4, 15, 45), at(5, 9, 30)},
+			end:   []time.Time{at(4, 9, 45), at(4, 10, 0), at(4, 16, 0), at(5, 9, 45)},
+		},
+		{
+			// Cut at the close instead of 16:30
+			name:  "hours anchored to the open",
+			tf:    NewTimeFrame(1, Hour),
+			bars:  bars,
+			start: []time.Time{at(4, 9, 30), at(4, 15, 30), at(5, 9, 30)},
+			end:   []time.Time{at(4, 10, 30), at(4, 16, 0), at(5, 10, 30)},
+		},
+		{
+			name:  "days",
+			tf:    NewTimeFrame(1, Day),
+			bars:  bars,
+			start: []time.Time{at(4, 0, 0), at(5, 0, 0)},
+			end:   []time.Time{at(4, 16, 0), at(5, 16, 0)},
+		},
+		{
+			// Ends on Friday's close
+			name:  "weeks",
+			tf:    NewTimeFrame(1, Week),
+			bars:  bars,
+			start: []time.Time{at(4, 0, 0)},
+			end:   []time.Time{at(8, 16, 0)},
+		},
+		{
+			name:  "outside of the session",
+			tf:    NewTimeFrame(15, Minute),
+			bars:  nyBars(at(4, 8, 0), at(4, 9, 30), at(4, 16, 0)),
+			start: []time.Time{at(4, 9, 30)},
+			end:   []time.Time{at(4, 9, 45)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ends := resample(tt.bars, tt.tf, NYSE)
+			if len(got) != len(tt.start) {
+				t.Fatalf("got %d bars, want %d", len(got), len(tt.start))
+			}
+			for i := range got {
+				if !got[i].Timestamp.Equal(tt.start[i]) || !ends[i].Equal(tt.end[i]) {
+					t.Errorf("bar %d: got %s to %s, want %s to %s", i, got[i].Timestamp, ends[i], tt.start[i], tt.end[i])
+				}
+			}
+		})
+	}
+
+	// The first 15 minutes aggregate the first 3 bars
+	b := Resample(bars, NewTimeFrame(15, Minute), NYSE)[0]
+	want := Bar{Timestamp: at(4, 9, 30), Open: 100, High: 103, Low: 99, Close: 102.5, Volume: 60, TradeCount: 3}
+	if b.Timestamp != want.Timestamp || b.Open != want.Open || b.High != want.High || b.Low != want.Low || b.Close != want.Close || b.Volume != want.Volume || b.TradeCount != want.TradeCount {
+		t.Errorf("got %+v, want %+v", b, want)
+	}
+	// VWAP is weighted by the volume of each bar
+	if want := (100*10 + 101*20 + 102*30) / 60.0; math.Abs(b.VWAP-want) > 1e-9 {
+		t.Errorf("vwap: got %f, want %f", b.VWAP, want)
+	}
+}
+
+// TestResampledNoLookAhead checks strategies only see periods completed by the
+// current bar.
+func TestResampledNoLookAhead(t *testing.T) {
+	tests := []struct {
+		name string
+		tf   TimeFrame
+		ts   []time.Time
+		// want is the number of completed periods at each bar
+		want []int
+	}{
+		{
+			name: "15 minutes",
+			tf:   NewTimeFrame(15, Minute),
+			ts:   nyTimes(4, 9, 30, 7),
+			want: []int{0, 0, 1, 1, 1, 2, 2},
+		},
+		{
+			// Completed by the last bar of the session without waiting for the next day
+			name: "days",
+			tf:   NewTimeFrame(1, Day),
+			ts:   append(nyTimes(4, 15, 50, 2), nyTimes(5, 9, 30, 2)...),
+			want: []int{0, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := nyBars(tt.ts...)
+			bt, err := New(map[string][]Bar{"X": bars}, WithSession(NYSE), WithResample(tt.tf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			all := Resample(bars, tt.tf, NYSE)
+			i := 0
+			_, err = bt.Strategy("test", func(s *Strategy) {
+				if s.Data["X"].Resampled(NewTimeFrame(2, Hour)) != nil {
+					t.Error("got bars resampled to a time frame not registered")
+				}
+				got := s.Data["X"].Resampled(tt.tf).Bars()
+				if len(got) != tt.want[i] {
+					t.Errorf("bar %d: got %d periods, want %d", i, len(got), tt.want[i])
+				} else if n := len(got); n > 0 && got[n-1] != all[n-1] {
+					t.Errorf("bar %d: got %+v, want %+v", i, got[n-1], all[n-1])
+				}
+				i++
+			}).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
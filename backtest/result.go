@@ -0,0 +1,336 @@
+package backtest
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Stats are the metrics of an account at the end of a run. Fields with the `Pct`
+// suffix are percentages e.g. 5.2 is 5.2%.
+type Stats struct {
+	Start               time.Time
+	End                 time.Time
+	Duration            time.Duration
+	ExposureTimePct     float64
+	AvgExposurePct      float64
+	MaxExposurePct      float64
+	EquityFinal         float64
+	EquityPeak          float64
+	ReturnPct           float64
+	BuyAndHoldReturnPct float64
+	// Annualized metrics are based on the bars' time frame and trading calendar.
+	ReturnAnnPct     float64
+	VolatilityAnnPct float64
+	SharpeRatio      float64
+	SortinoRatio     float64
+	CalmarRatio      float64
+	// Alpha and beta are against an equally weighted buy & hold of all symbols.
+	AlphaPct float64
+	Beta     float64
+	// MaxDrawdown is the largest drop from peak in cash units (negative).
+	MaxDrawdown    float64
+	MaxDrawdownPct float64
+	// AvgDrawdownPct is the average depth of drawdown periods.
+	AvgDrawdownPct      float64
+	MaxDrawdownDuration time.Duration
+	AvgDrawdownDuration time.Duration
+	// Trades is the number of closed trades which trade stats are based on.
+	Trades int
+	// OpenTrades is the number of trades still open at the end.
+	OpenTrades       int
+	WinRatePct       float64
+	BestTradePct     float64
+	WorstTradePct    float64
+	AvgTradePct      float64
+	MaxTradeDuration time.Duration
+	AvgTradeDuration time.Duration
+	ProfitFactor     float64
+	// Expectancy is the average PnL per trade in cash units.
+	Expectancy float64
+	SQN        float64
+	// KellyCriterion is the fraction of capital to risk per trade.
+	KellyCriterion float64
+	Fees           float64
+	Slippage       float64
+	RejectedOrders int
+	MarginCalls    int
+}
+
+// EquityPoint is the account's equity and drawdown from peak (percentage) at a bar.
+type EquityPoint struct {
+	Time        time.Time
+	Equity      float64
+	DrawdownPct float64
+}
+
+// Result is the outcome of running a backtest. When running more than one strategy
+// account, the top level result is all of them combined and `Accounts` holds the
+// result of each one of them.
+type Result struct {
+	// Name of the strategy or strategies (shared account) behind the result.
+	Name    string
+	Symbols []string
+	// Bars the backtest ran on keyed by symbol. Bar indexes match the equity curve's
+	// while those of trades and orders are `warmup` bars ahead with `WithWarmup`.
+	Bars  map[string][]Bar
+	Stats Stats
+	// Equity is the equity curve along with the drawdown series.
+	Equity []EquityPoint
+	// Trades are the closed trades sorted by exit.
+	Trades []*Trade
+	// Orders are all orders placed regardless of their status.
+	Orders   []*Order
+	Accounts []*Result
+
+	// warmup is the number of bars set aside with `WithWarmup`.
+	warmup int
+}
+
+// result builds the result of the run from the accounts.
+func (bt *Backtest) result() *Result {
+	var accounts []*Result
+	for _, a := range bt.accounts {
+		accounts = append(accounts, bt.accountResult(a.name, a.broker))
+	}
+	if len(accounts) == 1 {
+		return accounts[0]
+	}
+
+	r := bt.accountResult("combined", bt.combined())
+	r.Accounts = accounts
+	return r
+}
+
+// accountResult computes the stats, equity curve and drawdowns for the given account.
+// Trade stats are based on closed trades while trades still open at the end count
+// towards exposure and equity. Warm-up bars are left out.
+func (bt *Backtest) accountResult(name string, b *broker) *Result {
+	w := bt.opts.warmup
+	equities, exposures := b.equities[w:], b.exposures[w:]
+	last := len(equities) - 1
+	timestamp := func(i int) time.Time { return bt.timestamp(w + i) }
+
+	// Drawdowns are measured from the running peak. A drawdown period lasts from
+	// the peak until equity gets back to it or until the end when it never does.
+	var drawdowns = make([]float64, len(equities))
+	var depths []float64
+	var drawdownDurations []time.Duration
+	var maxDrawdown, depth float64
+	peakI := 0
+	for i, equity := range equities {
+		peak := equities[peakI]
+		if equity >= peak {
+			if depth < 0 {
+				depths = append(depths, depth)
+				drawdownDurations = append(drawdownDurations, timestamp(i).Sub(timestamp(peakI)))
+				depth = 0
+			}
+			peakI = i
+			continue
+		}
+		if peak > 0 {
+			drawdowns[i] = equity/peak - 1
+		}
+		depth = min(depth, drawdowns[i])
+		maxDrawdown = min(maxDrawdown, equity-peak)
+	}
+	if depth < 0 {
+		depths = append(depths, depth)
+		drawdownDurations = append(drawdownDurations, timestamp(last).Sub(timestamp(peakI)))
+	}
+
+	var returnsPct []float64
+	var pnls []float64
+	var durations []time.Duration
+	var exposure = make([]float64, len(equities))
+	for _, t := range b.closedTrades {
+		returnsPct = append(returnsPct, t.PnlPct())
+		pnls = append(pnls, t.Pnl())
+		durations = append(durations, t.ExitTime().Sub(t.EntryTime()))
+		for i := t.EntryBar; i <= t.ExitBar; i++ {
+			exposure[i-w] = 1
+		}
+	}
+	for _, t := range b.trades {
+		for i := t.EntryBar - w; i <= last; i++ {
+			exposure[i] = 1
+		}
+	}
+
+	var wrPct float64
+	if len(pnls) > 0 {
+		wrPct = float64(count(pnls, func(e float64) bool { return e > 0 })) / float64(len(pnls)) * 100
+	}
+
+	var retPct float64
+	if equities[0] != 0 {
+		retPct = (equities[last] - equities[0]) / equities[0] * 100
+	}
+
+	// Buy & hold return of an equally weighted portfolio of all symbols
+	var bhRetPct float64
+	for _, data := range bt.data {
+		first := data.bars[w].Close
+		bhRetPct += (data.LastClose() - first) / first * 100
+	}
+	bhRetPct /= float64(len(bt.data))
+
+	// Profit factor is infinite when there are profits but no losses
+	pos := sumFunc(pnls, func(e float64) bool { return e > 0 })
+	neg := math.Abs(sumFunc(pnls, func(e float64) bool { return e < 0 }))
+	var pf float64
+	if neg != 0 {
+		pf = pos / neg
+	} else if pos > 0 {
+		pf = math.Inf(1)
+	}
+
+	risk := bt.riskMetrics(equities, minOf(drawdowns))
+
+	equity := make([]EquityPoint, len(equities))
+	for i := range equities {
+		equity[i] = EquityPoint{
+			Time:        timestamp(i),
+			Equity:      equities[i],
+			DrawdownPct: drawdowns[i] * 100,
+		}
+	}
+
+	return &Result{
+		Name:    name,
+		Symbols: bt.symbols,
+		Bars:    bt.bars(),
+		Equity:  equity,
+		Trades:  b.closedTrades,
+		Orders:  b.history,
+		warmup:  w,
+		Stats: Stats{
+			Start:               timestamp(0),
+			End:                 timestamp(last),
+			Duration:            timestamp(last).Sub(timestamp(0)),
+			ExposureTimePct:     mean(exposure) * 100,
+			AvgExposurePct:      mean(exposures) * 100,
+			MaxExposurePct:      maxOf(exposures) * 100,
+			EquityFinal:         equities[last],
+			EquityPeak:          maxOf(equities),
+			ReturnPct:           retPct,
+			BuyAndHoldReturnPct: bhRetPct,
+			ReturnAnnPct:        risk.returnAnn * 100,
+			VolatilityAnnPct:    risk.volatilityAnn * 100,
+			SharpeRatio:         risk.sharpe,
+			SortinoRatio:        risk.sortino,
+			CalmarRatio:         risk.calmar,
+			AlphaPct:            risk.alpha * 100,
+			Beta:                risk.beta,
+			MaxDrawdown:         maxDrawdown,
+			MaxDrawdownPct:      minOf(drawdowns) * 100,
+			AvgDrawdownPct:      mean(depths) * 100,
+			MaxDrawdownDuration: maxOf(drawdownDurations),
+			AvgDrawdownDuration: mean(drawdownDurations),
+			Trades:              len(b.closedTrades),
+			OpenTrades:          len(b.trades),
+			WinRatePct:          wrPct,
+			BestTradePct:        maxOf(returnsPct) * 100,
+			WorstTradePct:       minOf(returnsPct) * 100,
+			AvgTradePct:         mean(returnsPct) * 100,
+			MaxTradeDuration:    maxOf(durations),
+			AvgTradeDuration:    mean(durations),
+			ProfitFactor:        pf,
+			Expectancy:          mean(pnls),
+			SQN:                 sqn(pnls),
+			KellyCriterion:      kelly(pnls),
+			Fees:                b.fees,
+			Slippage:            b.slippage,
+			RejectedOrders:      b.rejectedOrders,
+			MarginCalls:         b.marginCalls,
+		},
+	}
+}
+
+// Summary writes the stats of each account followed by all accounts combined
+// when running more than one.
+func (r *Result) Summary(w io.Writer) error {
+	for _, a := range r.Accounts {
+		if err := a.Summary(w); err != nil {
+			return err
+		}
+	}
+
+	data := r.rows()
+	row := slices.MaxFunc(data, func(a, b [2]string) int {
+		return cmp.Compare(len(a[0]), len(b[0]))
+	})
+	span := len(row[0]) + 1
+
+	var s strings.Builder
+	for _, d := range data {
+		var padding string
+		for i := 0; i < span-(len(d[0])+1); i++ {
+			padding += " "
+		}
+		s.WriteString(fmt.Sprintf("%s %s: %s\n", padding, d[0], d[1]))
+	}
+
+	_, err := fmt.Fprintln(w, s.String())
+	return err
+}
+
+// rows returns the stats as title cased label and formatted value pairs.
+func (r *Result) rows() [][2]string {
+	st := r.Stats
+	data := [][2]string{
+		{"strategy", r.Name},
+		{"symbols", strings.Join(r.Symbols, ", ")},
+		{"start", st.Start.String()},
+		{"end", st.End.String()},
+		{"duration", st.Duration.String()},
+		{"exposure time", fmt.Sprintf("%f%%", st.ExposureTimePct)},
+		{"avg. exposure", fmt.Sprintf("%f%%", st.AvgExposurePct)},
+		{"max. exposure", fmt.Sprintf("%f%%", st.MaxExposurePct)},
+		{"equity final", fmt.Sprintf("$%f", st.EquityFinal)},
+		{"equity peak", fmt.Sprintf("$%f", st.EquityPeak)},
+		{"return", fmt.Sprintf("%f%%", st.ReturnPct)},
+		{"buy & hold return", fmt.Sprintf("%f%%", st.BuyAndHoldReturnPct)},
+		{"return (ann.)", fmt.Sprintf("%f%%", st.ReturnAnnPct)},
+		{"volatility (ann.)", fmt.Sprintf("%f%%", st.VolatilityAnnPct)},
+		{"sharpe ratio", fmt.Sprintf("%f", st.SharpeRatio)},
+		{"sortino ratio", fmt.Sprintf("%f", st.SortinoRatio)},
+		{"calmar ratio", fmt.Sprintf("%f", st.CalmarRatio)},
+		{"alpha", fmt.Sprintf("%f%%", st.AlphaPct)},
+		{"beta", fmt.Sprintf("%f", st.Beta)},
+		{"max drawdown", fmt.Sprintf("$%f", st.MaxDrawdown)},
+		{"max drawdown pct", fmt.Sprintf("%f%%", st.MaxDrawdownPct)},
+		{"avg. drawdown", fmt.Sprintf("%f%%", st.AvgDrawdownPct)},
+		{"max. drawdown duration", st.MaxDrawdownDuration.String()},
+		{"avg. drawdown duration", st.AvgDrawdownDuration.String()},
+		{"# trades", strconv.Itoa(st.Trades)},
+		{"# open trades", strconv.Itoa(st.OpenTrades)},
+		{"win rate", fmt.Sprintf("%f%%", st.WinRatePct)},
+		{"best trade", fmt.Sprintf("%f%%", st.BestTradePct)},
+		{"worst trade", fmt.Sprintf("%f%%", st.WorstTradePct)},
+		{"avg. trade", fmt.Sprintf("%f%%", st.AvgTradePct)},
+		{"max. trade duration", st.MaxTradeDuration.String()},
+		{"avg. trade duration", st.AvgTradeDuration.String()},
+		{"profit factor", fmt.Sprintf("%f", st.ProfitFactor)},
+		{"expectancy", fmt.Sprintf("$%f", st.Expectancy)},
+		{"system quality number", fmt.Sprintf("%f", st.SQN)},
+		{"kelly criterion", fmt.Sprintf("%f", st.KellyCriterion)},
+		{"total fees", fmt.Sprintf("$%f", st.Fees)},
+		{"total slippage", fmt.Sprintf("$%f", st.Slippage)},
+		{"rejected orders", strconv.Itoa(st.RejectedOrders)},
+		{"margin calls", strconv.Itoa(st.MarginCalls)},
+	}
+	for i := range data {
+		data[i][0] = cases.Title(language.English).String(data[i][0])
+	}
+	return data
+}
@@ -0,0 +1,119 @@
+package backtest
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip buys a unit on the first bar and sells it on the third one.
+func roundTrip(s *Strategy) {
+	switch len(s.Data["X"].Bars()) {
+	case 1:
+		s.Buy("X", TradeOpts{Size: 1})
+	case 3:
+		s.Sell("X", TradeOpts{Size: 1})
+	}
+}
+
+func TestResult(t *testing.T) {
+	// Bought at 100 on the second bar and sold at 110 on the last one
+	bars := dailyBars(100, 100, 110, 110)
+	res := runBars(t, bars, roundTrip)
+	st := res.Stats
+	assertFinite(t, st)
+
+	if res.Name != "test" || len(res.Symbols) != 1 || len(res.Bars["X"]) != len(bars) {
+		t.Errorf("got %q result on %v with %d bars, want test on X with %d", res.Name, res.Symbols, len(res.Bars["X"]), len(bars))
+	}
+	if st.Start != bars[0].Timestamp || st.End != bars[3].Timestamp || st.Duration != 3*24*time.Hour {
+		t.Errorf("period: got %s to %s (%s), want 3 days from %s", st.Start, st.End, st.Duration, bars[0].Timestamp)
+	}
+
+	wantEquity := []float64{1000, 1000, 1010, 1010}
+	if len(res.Equity) != len(wantEquity) {
+		t.Fatalf("got %d equity points, want %d", len(res.Equity), len(wantEquity))
+	}
+	for i, p := range res.Equity {
+		if p.Time != bars[i].Timestamp || p.Equity != wantEquity[i] || p.DrawdownPct != 0 {
+			t.Errorf("equity %d: got %+v, want %f at %s", i, p, wantEquity[i], bars[i].Timestamp)
+		}
+	}
+
+	if len(res.Trades) != 1 || len(res.Orders) != 2 {
+		t.Fatalf("got %d trades and %d orders, want 1 and 2", len(res.Trades), len(res.Orders))
+	}
+	for name, got := range map[string]float64{
+		"final equity":      st.EquityFinal - 1010,
+		"peak equity":       st.EquityPeak - 1010,
+		"return":            st.ReturnPct - 1,
+		"buy & hold return": st.BuyAndHoldReturnPct - 10,
+		"exposure":          st.ExposureTimePct - 75,
+		"win rate":          st.WinRatePct - 100,
+		"best trade":        st.BestTradePct - 10,
+		"worst trade":       st.WorstTradePct - 10,
+		"avg. trade":        st.AvgTradePct - 10,
+		"expectancy":        st.Expectancy - 10,
+	} {
+		if math.Abs(got) > 1e-9 {
+			t.Errorf("%s: off by %f", name, got)
+		}
+	}
+	if st.Trades != 1 || st.OpenTrades != 0 {
+		t.Errorf("trades: got %d closed and %d open, want 1 and 0", st.Trades, st.OpenTrades)
+	}
+	if st.MaxTradeDuration != 2*24*time.Hour || st.AvgTradeDuration != st.MaxTradeDuration {
+		t.Errorf("trade duration: got max %s and avg %s, want 48h", st.MaxTradeDuration, st.AvgTradeDuration)
+	}
+	// There are no losses to divide profits by
+	if !math.IsInf(st.ProfitFactor, 1) {
+		t.Errorf("profit factor: got %f, want +Inf", st.ProfitFactor)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	res := runBars(t, dailyBars(100, 100, 110, 110), roundTrip)
+	var sb strings.Builder
+	if err := res.Summary(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{"Strategy: test", "Symbols: X", "Equity Final: $1010.000000", "Return: 1.000000%", "# Trades: 1", "Margin Calls: 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("got %q, want it to contain %q", out, want)
+		}
+	}
+	// Labels are right aligned on the colon
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	col := strings.Index(lines[0], ":")
+	for _, line := range lines {
+		if strings.Index(line, ":") != col {
+			t.Errorf("got %q, want its colon at %d", line, col)
+		}
+	}
+	if len(lines) != len(res.rows()) {
+		t.Errorf("got %d lines, want one per stat (%d)", len(lines), len(res.rows()))
+	}
+}
+
+func TestSummaryAccounts(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 100, 110, 110)}, WithCash(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := bt.Strategy("a", roundTrip).Strategy("b", buyOnce).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := res.Summary(&sb); err != nil {
+		t.Fatal(err)
+	}
+	// Each account is followed by all of them combined
+	out := sb.String()
+	a, b, combined := strings.Index(out, "Strategy: a\n"), strings.Index(out, "Strategy: b\n"), strings.Index(out, "Strategy: combined\n")
+	if a < 0 || b < a || combined < b {
+		t.Errorf("got %q, want a, b and combined summaries in order", out)
+	}
+}
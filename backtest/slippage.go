@@ -0,0 +1,55 @@
+package backtest
+
+import "math"
+
+// SlippageModel computes the per unit price adjustment applied against us when
+// filling market and stop orders. `bar` is the bar the order is filled at.
+type SlippageModel interface {
+	Slippage(side Side, size, price float64, bar Bar) float64
+}
+
+// FixedSlippage moves the fill price by a fixed number of ticks. `TickSize`
+// defaults to 0.01 when not set.
+type FixedSlippage struct {
+	Ticks    float64
+	TickSize float64
+}
+
+func (s FixedSlippage) Slippage(side Side, size, price float64, bar Bar) float64 {
+	tickSize := s.TickSize
+	if tickSize == 0 {
+		tickSize = 0.01
+	}
+	return s.Ticks * tickSize
+}
+
+// PercentSlippage moves the fill price by a percentage of the price
+// e.g. PercentSlippage(0.0005) is 5 basis points.
+type PercentSlippage float64
+
+func (s PercentSlippage) Slippage(side Side, size, price float64, bar Bar) float64 {
+	return price * float64(s)
+}
+
+// VolumeSlippage models market impact based on the order's participation in the
+// bar's volume: price × PriceImpact × (size / volume)². Participation is capped at
+// 1 and bars without volume are treated as full participation.
+type VolumeSlippage struct {
+	PriceImpact float64
+}
+
+func (s VolumeSlippage) Slippage(side Side, size, price float64, bar Bar) float64 {
+	participation := 1.0
+	if bar.Volume > 0 {
+		participation = min(math.Abs(size)/bar.Volume, 1)
+	}
+	return price * s.PriceImpact * participation * participation
+}
+
+// SpreadSlippage simulates crossing the bid/ask spread by moving the fill price by
+// half of the given (absolute) spread.
+type SpreadSlippage float64
+
+func (s SpreadSlippage) Slippage(side Side, size, price float64, bar Bar) float64 {
+	return float64(s) / 2
+}
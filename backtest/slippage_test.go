@@ -0,0 +1,89 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSlippageModels(t *testing.T) {
+	bar := Bar{Volume: 1000}
+	tests := []struct {
+		name  string
+		model SlippageModel
+		size  float64
+		want  float64
+	}{
+		{"fixed", FixedSlippage{Ticks: 2, TickSize: 0.05}, 10, 0.1},
+		{"fixed with default tick size", FixedSlippage{Ticks: 2}, 10, 0.02},
+		{"percent", PercentSlippage(0.0005), 10, 0.05},
+		{"volume", VolumeSlippage{PriceImpact: 0.1}, 500, 2.5},
+		{"volume of a short", VolumeSlippage{PriceImpact: 0.1}, -500, 2.5},
+		{"volume above the bar's", VolumeSlippage{PriceImpact: 0.1}, 2000, 10},
+		{"spread", SpreadSlippage(0.04), 10, 0.02},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Slippage(Buy, tt.size, 100, bar); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+
+	// Bars without volume are full participation
+	if got := (VolumeSlippage{PriceImpact: 0.1}).Slippage(Buy, 1, 100, Bar{}); math.Abs(got-10) > 1e-9 {
+		t.Errorf("no volume: got %f, want 10", got)
+	}
+}
+
+func TestSlippageApplied(t *testing.T) {
+	// Orders are placed on the first bar, filled on the second one and trades are
+	// closed at the last bar's open of 110.
+	bars := dailyBars(100, 100, 105, 110, 110)
+	tests := []struct {
+		name     string
+		model    SlippageModel
+		sell     bool
+		opts     TradeOpts
+		entry    float64
+		exit     float64
+		slippage [2]float64
+	}{
+		{"market buy", FixedSlippage{Ticks: 10}, false, TradeOpts{Size: 1}, 100.1, 109.9, [2]float64{0.1, 0.1}},
+		{"market sell", FixedSlippage{Ticks: 10}, true, TradeOpts{Size: 1}, 99.9, 110.1, [2]float64{0.1, 0.1}},
+		// Hit on the third bar opening below the stop
+		{"stop", FixedSlippage{Ticks: 10}, false, TradeOpts{Size: 1, Stop: 104}, 104.1, 109.9, [2]float64{0.1, 0.1}},
+		{"limit", FixedSlippage{Ticks: 10}, false, TradeOpts{Size: 1, Limit: 101}, 100, 109.9, [2]float64{0, 0.1}},
+		// Models can't move prices in our favor
+		{"negative", PercentSlippage(-0.01), false, TradeOpts{Size: 1}, 100, 110, [2]float64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := runBars(t, bars, func(s *Strategy) {
+				switch len(s.Data["X"].Bars()) {
+				case 1:
+					if tt.sell {
+						s.Sell("X", tt.opts)
+					} else {
+						s.Buy("X", tt.opts)
+					}
+				case len(bars) - 1:
+					s.Positions["X"].Close(1)
+				}
+			}, WithSlippage(tt.model))
+
+			if len(res.Trades) != 1 {
+				t.Fatalf("got %d closed trades, want 1", len(res.Trades))
+			}
+			trade := res.Trades[0]
+			if math.Abs(trade.EntryPrice-tt.entry) > 1e-9 || math.Abs(trade.ExitPrice-tt.exit) > 1e-9 {
+				t.Errorf("prices: got %f to %f, want %f to %f", trade.EntryPrice, trade.ExitPrice, tt.entry, tt.exit)
+			}
+			if got := [2]float64{trade.EntrySlippage, trade.ExitSlippage}; math.Abs(got[0]-tt.slippage[0]) > 1e-9 || math.Abs(got[1]-tt.slippage[1]) > 1e-9 {
+				t.Errorf("slippage: got %v, want %v", got, tt.slippage)
+			}
+			if want := trade.Slippage(); math.Abs(res.Stats.Slippage-want) > 1e-9 {
+				t.Errorf("total slippage: got %f, want %f", res.Stats.Slippage, want)
+			}
+		})
+	}
+}
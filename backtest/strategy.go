@@ -1,14 +1,108 @@
 package backtest
 
+import "fmt"
+
+// strategy is a registered user defined callback along with its account.
+type strategy struct {
+	name    string
+	cb      func(s *Strategy)
+	opts    strategyOpts
+	account *account
+	s       *Strategy
+}
+
+type strategyOpts struct {
+	// Value between 0 and 1 sets the percentage of cash allocated to the strategy's
+	// account. Defaults to an even split of the cash left unallocated.
+	allocation float64
+	// When true the strategy trades the account shared with other strategies.
+	shared bool
+	// When true the strategy is also called on every tick.
+	tickEvents bool
+}
+
+// StrategyOption configures how a strategy is run. See `Backtest.Strategy`.
+type StrategyOption func(o *strategyOpts) error
+
+// WithAllocation sets the percentage of cash in (0, 1] allocated to the strategy.
+// For strategies sharing an account, allocations are added up.
+func WithAllocation(pct float64) StrategyOption {
+	return func(o *strategyOpts) error {
+		if pct <= 0 || pct > 1 {
+			return fmt.Errorf("%w: allocation (%f) must be in (0, 1]", ErrInvalidOption, pct)
+		}
+		o.allocation = pct
+		return nil
+	}
+}
+
+// WithSharedAccount makes the strategy trade the account shared by all strategies
+// registered with this option instead of its own.
+func WithSharedAccount() StrategyOption {
+	return func(o *strategyOpts) error {
+		o.shared = true
+		return nil
+	}
+}
+
+// WithTickEvents calls the strategy on every tick provided with `WithTicks` on top
+// of every bar close. `Strategy.Tick` tells which event the strategy is called on.
+func WithTickEvents() StrategyOption {
+	return func(o *strategyOpts) error {
+		o.tickEvents = true
+		return nil
+	}
+}
+
+// call refreshes the strategy's orders, trades and indicators on bar closes and
+// calls the user's callback. It returns the error the callback failed with if any.
+// See `Strategy.Fail`.
+func (st *strategy) call(tick *Tick) error {
+	st.s.Tick = tick
+	if tick == nil {
+		st.s.advance()
+	}
+	st.s.Orders = st.s.broker.orders
+	st.s.Trades = st.s.broker.trades
+	st.s.ClosedTrades = st.s.broker.closedTrades
+	st.cb(st.s)
+	if st.s.err != nil {
+		return fmt.Errorf("strategy %s: %w", st.name, st.s.err)
+	}
+	return nil
+}
+
 type Strategy struct {
-	broker       *broker
-	Data         *Data
-	Position     *Position
+	broker *broker
+	// series are the indicators registered with `I`.
+	series map[seriesKey]*series
+	// err is the error the strategy failed with. See `Fail`.
+	err error
+	// Tick is the tick the strategy is called on or nil on bar closes. See
+	// `WithTickEvents`.
+	Tick *Tick
+	// Symbols are the sorted keys of `Data` and `Positions`.
+	Symbols      []string
+	Data         map[string]*Data
+	Positions    map[string]*Position
 	Orders       []*Order
 	Trades       []*Trade
 	ClosedTrades []*Trade
 }
 
+// Fail stops the backtest after the current callback returns. `Backtest.Run`
+// returns err wrapped with the strategy's name. Only the first error is kept.
+//
+//	if _, err := s.Buy("SPY", backtest.TradeOpts{SL: sl}); err != nil {
+//		s.Fail(err)
+//		return
+//	}
+func (s *Strategy) Fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
 type TradeOpts struct {
 	Size  float64
 	Stop  float64
@@ -18,26 +112,32 @@ type TradeOpts struct {
 	Trade *Trade
 }
 
-func (s Strategy) Buy(opts TradeOpts) {
-	s.broker.newOrder(newOrderOpts{
-		side:  Buy,
-		size:  opts.Size,
-		stop:  opts.Stop,
-		limit: opts.Limit,
-		sl:    opts.SL,
-		tp:    opts.TP,
-		trade: opts.Trade,
+// Buy places a long order for the given symbol. An error is returned when the order
+// doesn't pass validation e.g. unknown symbol, invalid bracket or fractional size.
+func (s Strategy) Buy(symbol string, opts TradeOpts) (*Order, error) {
+	return s.broker.newOrder(newOrderOpts{
+		symbol: symbol,
+		side:   Buy,
+		size:   opts.Size,
+		stop:   opts.Stop,
+		limit:  opts.Limit,
+		sl:     opts.SL,
+		tp:     opts.TP,
+		trade:  opts.Trade,
 	})
 }
 
-func (s Strategy) Sell(opts TradeOpts) {
-	s.broker.newOrder(newOrderOpts{
-		side:  Sell,
-		size:  opts.Size,
-		stop:  opts.Stop,
-		limit: opts.Limit,
-		sl:    opts.SL,
-		tp:    opts.TP,
-		trade: opts.Trade,
+// Sell places a short order for the given symbol. An error is returned when the order
+// doesn't pass validation e.g. unknown symbol, invalid bracket or fractional size.
+func (s Strategy) Sell(symbol string, opts TradeOpts) (*Order, error) {
+	return s.broker.newOrder(newOrderOpts{
+		symbol: symbol,
+		side:   Sell,
+		size:   opts.Size,
+		stop:   opts.Stop,
+		limit:  opts.Limit,
+		sl:     opts.SL,
+		tp:     opts.TP,
+		trade:  opts.Trade,
 	})
 }
@@ -0,0 +1,34 @@
+package backtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrategyFail(t *testing.T) {
+	bt, err := New(map[string][]Bar{"X": dailyBars(100, 101, 102, 103)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	bt.Strategy("invalid", func(s *Strategy) {
+		calls++
+		if len(s.Data["X"].Bars()) == 2 {
+			_, err := s.Buy("X", TradeOpts{Size: 1.5})
+			s.Fail(err)
+			s.Fail(errors.New("ignored"))
+		}
+	})
+
+	_, err = bt.Run()
+	if !errors.Is(err, ErrFractionalSize) {
+		t.Fatalf("got %v, want %v", err, ErrFractionalSize)
+	}
+	if want := "strategy invalid: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got %q, want it prefixed with %q", err, want)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want the run to stop after 2", calls)
+	}
+}
@@ -0,0 +1,113 @@
+package backtest
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+type TickKind string
+
+const (
+	// TradeTick is a trade printed on the tape at `Price` for `Size` units.
+	TradeTick TickKind = "trade"
+	// QuoteTick is an update of the best bid and ask.
+	QuoteTick TickKind = "quote"
+)
+
+// Tick is a trade or quote from the tape. Ticks let the broker fill orders in the
+// order prices were actually reached within a bar. See `WithTicks`.
+type Tick struct {
+	// Symbol is set from the key ticks are passed with to `WithTicks`.
+	Symbol    string
+	Timestamp time.Time
+	Kind      TickKind
+	// Price and size of trades.
+	Price float64
+	Size  float64
+	// Best bid and ask of quotes.
+	Bid     float64
+	Ask     float64
+	BidSize float64
+	AskSize float64
+}
+
+// Mid returns the midpoint of a quote or the price of a trade.
+func (t Tick) Mid() float64 {
+	if t.Kind == QuoteTick {
+		return (t.Bid + t.Ask) / 2
+	}
+	return t.Price
+}
+
+// fillPrice returns the price an order of the given side can be filled at. Buys
+// lift the ask and sells hit the bid of quotes. `WithTicks` makes sure quotes have
+// both sides.
+func (t Tick) fillPrice(side Side) float64 {
+	if t.Kind != QuoteTick {
+		return t.Price
+	}
+	if side == Buy {
+		return t.Ask
+	}
+	return t.Bid
+}
+
+// bar returns a one price bar at the tick's fill price for the given side so that
+// orders are evaluated against the tick the same way they are against bars.
+func (t Tick) bar(side Side) Bar {
+	price := t.fillPrice(side)
+	return Bar{
+		Timestamp: t.Timestamp,
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Volume:    t.Size,
+	}
+}
+
+// sortTicks returns a copy of ticks sorted by timestamp with their symbol set.
+func sortTicks(symbol string, ticks []Tick) []Tick {
+	ticks = slices.Clone(ticks)
+	for i := range ticks {
+		ticks[i].Symbol = symbol
+	}
+	slices.SortStableFunc(ticks, func(a, b Tick) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return ticks
+}
+
+// tickEnds returns for each bar the index right after its last tick. A tick belongs
+// to the bar starting at or before its timestamp and before the next bar. The last
+// bar ends after the time frame's duration.
+func tickEnds(bars []Bar, ticks []Tick, tf TimeFrame) []int {
+	ends := make([]int, len(bars))
+	j := 0
+	for i, bar := range bars {
+		end := bar.Timestamp.Add(tf.Duration())
+		if i < len(bars)-1 {
+			end = bars[i+1].Timestamp
+		}
+		for j < len(ticks) && ticks[j].Timestamp.Before(end) {
+			j++
+		}
+		ends[i] = j
+	}
+	return ends
+}
+
+// tape merges the ticks of all symbols within bar i in timestamp order. Ticks at
+// the same timestamp are ordered by symbol.
+func tape(data map[string]*Data, symbols []string, i int) []Tick {
+	var ticks []Tick
+	for _, symbol := range symbols {
+		from, to := data[symbol].barTicks(i)
+		ticks = append(ticks, data[symbol].ticks[from:to]...)
+	}
+	slices.SortStableFunc(ticks, func(a, b Tick) int {
+		return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.Symbol, b.Symbol))
+	})
+	return ticks
+}
@@ -0,0 +1,86 @@
+package backtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuoteTicks(t *testing.T) {
+	bars := dailyBars(100, 100, 100)
+	at := bars[1].Timestamp.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		bid, ask float64
+		err      error
+	}{
+		{"only ask", 0, 101, ErrInvalidPrice},
+		{"only bid", 99, 0, ErrInvalidPrice},
+		{"both sides", 99, 101, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticks := map[string][]Tick{"X": {{Timestamp: at, Kind: QuoteTick, Bid: tt.bid, Ask: tt.ask}}}
+			bt, err := New(map[string][]Bar{"X": bars}, WithCash(1000), WithTicks(ticks))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			res, err := bt.Strategy("test", func(s *Strategy) {
+				if len(s.Data["X"].Bars()) == 1 {
+					s.Sell("X", TradeOpts{Size: 1})
+				}
+			}).Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Sells hit the bid
+			if o := res.Orders[0]; o.FillPrice != tt.bid {
+				t.Errorf("got fill at %f, want %f", o.FillPrice, tt.bid)
+			}
+		})
+	}
+}
+
+func TestTickEventsClosedBars(t *testing.T) {
+	bars := []Bar{
+		{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Open: 100, High: 101, Low: 100, Close: 101},
+		{Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Open: 101, High: 110, Low: 95, Close: 105},
+		{Timestamp: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Open: 105, High: 107, Low: 104, Close: 106},
+	}
+	var ticks []Tick
+	for i, prices := range [][]float64{{101}, {110, 95, 105}, {107, 104, 106}} {
+		for j, p := range prices {
+			ticks = append(ticks, Tick{Timestamp: bars[i].Timestamp.Add(time.Duration(j+1) * time.Hour), Kind: TradeTick, Price: p, Size: 1})
+		}
+	}
+	bt, err := New(map[string][]Bar{"X": bars}, WithTicks(map[string][]Tick{"X": ticks}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	bt.Strategy("test", func(s *Strategy) {
+		bs := s.Data["X"].Bars()
+		// Every bar but the forming one is the actual bar
+		n := len(bs)
+		if s.Tick != nil {
+			n--
+		}
+		for i := range n {
+			if bs[i] != bars[i] {
+				t.Errorf("bar %d on the %d-th event: got %+v, want %+v", i, events, bs[i], bars[i])
+			}
+		}
+		events++
+	}, WithTickEvents())
+
+	if _, err := bt.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if events != len(ticks)+len(bars) {
+		t.Errorf("got %d events, want %d", events, len(ticks)+len(bars))
+	}
+}
@@ -0,0 +1,114 @@
+package backtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TimeFrameUnit string
+
+const (
+	Minute TimeFrameUnit = "min"
+	Hour   TimeFrameUnit = "hour"
+	Day    TimeFrameUnit = "day"
+	Week   TimeFrameUnit = "week"
+	Month  TimeFrameUnit = "month"
+)
+
+// TimeFrame is the period each bar covers e.g. 5 minutes.
+type TimeFrame struct {
+	N    int
+	Unit TimeFrameUnit
+}
+
+// NewTimeFrame returns a time frame of n units.
+func NewTimeFrame(n int, unit TimeFrameUnit) TimeFrame {
+	return TimeFrame{N: n, Unit: unit}
+}
+
+// String returns the time frame e.g. "5min" or "1day".
+func (tf TimeFrame) String() string {
+	return fmt.Sprintf("%d%s", tf.N, tf.Unit)
+}
+
+// ParseTimeFrame parses a time frame in the format returned by `String` e.g.
+// "5min", "1hour", "1day", "1week" or "1month". Units can be shortened to m, h,
+// d, w and mo and the number defaults to 1 e.g. "15m" or "day".
+func ParseTimeFrame(s string) (TimeFrame, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		return TimeFrame{}, fmt.Errorf("%w: time frame %q has no unit", ErrInvalidOption, s)
+	}
+	n := 1
+	if i > 0 {
+		var err error
+		if n, err = strconv.Atoi(s[:i]); err != nil {
+			return TimeFrame{}, fmt.Errorf("%w: time frame %q: %w", ErrInvalidOption, s, err)
+		}
+	}
+	unit, ok := map[string]TimeFrameUnit{
+		"min": Minute, "m": Minute,
+		"hour": Hour, "h": Hour,
+		"day": Day, "d": Day,
+		"week": Week, "w": Week,
+		"month": Month, "mo": Month,
+	}[s[i:]]
+	tf := NewTimeFrame(n, unit)
+	if !ok || !tf.valid() {
+		return TimeFrame{}, fmt.Errorf("%w: invalid time frame %q", ErrInvalidOption, s)
+	}
+	return tf, nil
+}
+
+// valid reports whether the time frame has a known unit and a positive length.
+func (tf TimeFrame) valid() bool {
+	switch tf.Unit {
+	case Minute, Hour, Day, Week, Month:
+		return tf.N > 0
+	}
+	return false
+}
+
+// timeFrameOf returns the time frame of the given duration in the largest unit
+// it's a multiple of up to days.
+func timeFrameOf(d time.Duration) TimeFrame {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return NewTimeFrame(int(d/(24*time.Hour)), Day)
+	case d >= time.Hour && d%time.Hour == 0:
+		return NewTimeFrame(int(d/time.Hour), Hour)
+	default:
+		return NewTimeFrame(max(int(d/time.Minute), 1), Minute)
+	}
+}
+
+// inferTimeFrame returns the time frame of bars as the smallest gap between them.
+// It defaults to 1 day when there aren't enough bars to tell.
+func inferTimeFrame(bars []Bar) TimeFrame {
+	var gap time.Duration
+	for i := 1; i < len(bars); i++ {
+		if d := bars[i].Timestamp.Sub(bars[i-1].Timestamp); d > 0 && (gap == 0 || d < gap) {
+			gap = d
+		}
+	}
+	if gap == 0 {
+		return NewTimeFrame(1, Day)
+	}
+	return timeFrameOf(gap)
+}
+
+// Duration returns the calendar duration of the time frame. Months are
+// approximated to 30 days.
+func (tf TimeFrame) Duration() time.Duration {
+	unit := map[TimeFrameUnit]time.Duration{
+		Minute: time.Minute,
+		Hour:   time.Hour,
+		Day:    24 * time.Hour,
+		Week:   7 * 24 * time.Hour,
+		Month:  30 * 24 * time.Hour,
+	}[tf.Unit]
+	return time.Duration(tf.N) * unit
+}
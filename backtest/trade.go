@@ -1,7 +1,7 @@
 package backtest
 
 import (
-	"log"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,12 +9,18 @@ import (
 
 type Trade struct {
 	Id         string
+	Symbol     string
 	Size       float64
 	Side       Side
 	EntryPrice float64
 	ExitPrice  float64
 	EntryBar   int
 	ExitBar    int
+	EntryFee   float64
+	ExitFee    float64
+	// Per unit price adjustment applied against us on entry and exit fills.
+	EntrySlippage float64
+	ExitSlippage  float64
 
 	// legs keep track of trade's contingent orders i.e. stop and profit orders.
 	legs [2]*Order
@@ -34,35 +40,42 @@ func (t *Trade) indexOf() int {
 }
 
 // setContingent sets `setStopLoss` and `setTakeProfit` lengs index(`i`).
-func (t *Trade) setContingent(i int, price float64) {
+func (t *Trade) setContingent(i int, price float64) error {
 	if price <= 0 {
-		log.Fatalf("Price (%f) must be greater than 0\n", price)
+		return fmt.Errorf("%w: price (%f) must be greater than 0", ErrInvalidPrice, price)
 	}
 	o := t.legs[i]
-	if o != nil {
-		o.remove()
+	if o != nil && o.indexOf() >= 0 {
+		if err := o.remove(); err != nil {
+			return err
+		}
 	}
-	o = t.broker.newOrder(newOrderOpts{
-		size:  t.Size,
-		side:  reverseSide(t.Side),
-		trade: t,
+	o, err := t.broker.newOrder(newOrderOpts{
+		symbol: t.Symbol,
+		size:   t.Size,
+		side:   reverseSide(t.Side),
+		trade:  t,
 	})
+	if err != nil {
+		return err
+	}
 	if i == 0 {
 		o.Stop = price
 	} else {
 		o.Limit = price
 	}
 	t.legs[i] = o
+	return nil
 }
 
 // SetSL helps with setting trade's stop loss order.
-func (t *Trade) SetSL(price float64) {
-	t.setContingent(0, price)
+func (t *Trade) SetSL(price float64) error {
+	return t.setContingent(0, price)
 }
 
 // SetTP helps with setting trade's take profit order.
-func (t *Trade) SetTP(price float64) {
-	t.setContingent(1, price)
+func (t *Trade) SetTP(price float64) error {
+	return t.setContingent(1, price)
 }
 
 // isLong is true when side is `Buy`
@@ -77,17 +90,22 @@ func (t *Trade) IsShort() bool {
 
 // EntryTime returns a `time.Time` when trade was entered.
 func (t *Trade) EntryTime() time.Time {
-	return t.broker.data.bars[t.EntryBar].Timestamp
+	return t.broker.data[t.Symbol].bars[t.EntryBar].Timestamp
 }
 
 // ExitTime returns a `time.Time` when trade was exited.
 func (t *Trade) ExitTime() time.Time {
-	return t.broker.data.bars[t.ExitBar].Timestamp
+	return t.broker.data[t.Symbol].bars[t.ExitBar].Timestamp
 }
 
-// Pnl calculates profits and losses per trade.
+// Pnl calculates profits and losses per trade net of fees.
 func (t *Trade) Pnl() float64 {
-	price := t.broker.data.LastClose()
+	return t.grossPnl() - t.Fees()
+}
+
+// grossPnl calculates profits and losses per trade before fees.
+func (t *Trade) grossPnl() float64 {
+	price := t.broker.data[t.Symbol].LastClose()
 	if t.ExitPrice > 0 {
 		price = t.ExitPrice
 	}
@@ -97,21 +115,29 @@ func (t *Trade) Pnl() float64 {
 	return (t.EntryPrice - price) * t.Size
 }
 
-// PnlPct is a percentage representation of `t.Pnl()`
+// Slippage returns the cost of slippage in cash units for both entry and exit fills.
+// Notice it's already accounted for in the entry and exit prices.
+func (t *Trade) Slippage() float64 {
+	return (t.EntrySlippage + t.ExitSlippage) * t.Size
+}
+
+// Fees returns the sum of entry and exit commissions paid for the trade.
+func (t *Trade) Fees() float64 {
+	return t.EntryFee + t.ExitFee
+}
+
+// PnlPct is `t.Pnl()` as a fraction of the trade's entry value e.g. 0.05 for 5%.
 func (t *Trade) PnlPct() float64 {
-	price := t.broker.data.LastClose()
-	if t.ExitPrice > 0 {
-		price = t.ExitPrice
+	cost := t.EntryPrice * t.Size
+	if cost == 0 {
+		return 0
 	}
-	if t.IsLong() {
-		return (price/t.EntryPrice - 1) * t.Size
-	}
-	return (t.EntryPrice/price - 1) * t.Size
+	return t.Pnl() / cost
 }
 
 // Value returns trade total value in cash (volume × price).
 func (t *Trade) Value() float64 {
-	price := t.broker.data.LastClose()
+	price := t.broker.data[t.Symbol].LastClose()
 	if t.ExitPrice > 0 {
 		price = t.ExitPrice
 	}
@@ -121,12 +147,16 @@ func (t *Trade) Value() float64 {
 // Close places a new market order in the opposite direction to handle the closure of the trade.
 func (t *Trade) Close() {
 	o := &Order{
-		Id:     uuid.NewString(),
-		Side:   reverseSide(t.Side),
-		Size:   t.Size, // I'm not 100% sure about this?
-		trade:  t,
-		broker: t.broker,
+		Id:         uuid.NewString(),
+		Symbol:     t.Symbol,
+		Side:       reverseSide(t.Side),
+		Size:       t.Size, // I'm not 100% sure about this?
+		Status:     Pending,
+		CreatedBar: t.broker.barI(),
+		trade:      t,
+		broker:     t.broker,
 	}
+	t.broker.history = append(t.broker.history, o)
 	// Add new order to the front of the queue
 	t.broker.orders = append([]*Order{o}, t.broker.orders...)
 }
@@ -1,7 +1,9 @@
 package backtest
 
 import (
+	"cmp"
 	"math"
+	"slices"
 
 	"golang.org/x/exp/constraints"
 )
@@ -45,6 +47,38 @@ func count[S []E, E constraints.Integer | constraints.Float](s S, pred func(x E)
 	return count
 }
 
+// mean returns the average of s or 0 when empty.
 func mean[S []E, E constraints.Integer | constraints.Float](s S) E {
+	if len(s) == 0 {
+		return 0
+	}
 	return sum(s) / E(len(s))
 }
+
+// maxOf returns the maximum of s or the zero value when empty.
+func maxOf[S ~[]E, E cmp.Ordered](s S) E {
+	if len(s) == 0 {
+		var zero E
+		return zero
+	}
+	return slices.Max(s)
+}
+
+// minOf returns the minimum of s or the zero value when empty.
+func minOf[S ~[]E, E cmp.Ordered](s S) E {
+	if len(s) == 0 {
+		var zero E
+		return zero
+	}
+	return slices.Min(s)
+}
+
+// symbols returns the sorted keys of the given map.
+func symbols[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, cmp.Compare[string])
+	return keys
+}
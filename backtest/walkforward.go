@@ -0,0 +1,177 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Windows configures how bars are split for a walk-forward analysis. Sizes are
+// in number of bars.
+type Windows struct {
+	// InSample bars parameters are optimized on.
+	InSample int
+	// OutOfSample bars the best parameters are then evaluated on. Windows move
+	// forward by this many bars.
+	OutOfSample int
+	// Anchored keeps in-sample windows starting at the first bar so they grow
+	// instead of rolling.
+	Anchored bool
+}
+
+// Window is a single in-sample optimization followed by its out-of-sample run.
+type Window struct {
+	InSampleStart    time.Time
+	InSampleEnd      time.Time
+	OutOfSampleStart time.Time
+	OutOfSampleEnd   time.Time
+	// InSample is the best trial of the in-sample optimization.
+	InSample *Trial
+	// OutOfSample is the result of running the best parameters out-of-sample.
+	OutOfSample *Result
+	// Score is the out-of-sample result's metric.
+	Score float64
+}
+
+// WalkForwardResult holds every window along with their out-of-sample equity
+// curves stitched together as if a single account traded all of them.
+type WalkForwardResult struct {
+	Windows        []*Window
+	Equity         []EquityPoint
+	ReturnPct      float64
+	MaxDrawdownPct float64
+	Trades         int
+}
+
+// WalkForward splits bars in consecutive in-sample and out-of-sample windows. On
+// each window, parameters are optimized in-sample with `Optimize` and the best
+// ones are run on the following out-of-sample bars. Options are the same as
+// `Optimize` and apply to both runs e.g. the metric. Out-of-sample runs are warmed
+// up with the `InSample` bars preceding them so that indicators are ready to trade
+// from their first bar.
+func WalkForward(bars map[string][]Bar, w Windows, grid Grid, strategy func(p Params) func(s *Strategy), opts ...OptimizeOption) (*WalkForwardResult, error) {
+	if w.InSample <= 0 || w.OutOfSample <= 0 {
+		return nil, fmt.Errorf("%w: in-sample (%d) and out-of-sample (%d) windows must be greater than 0", ErrInvalidOption, w.InSample, w.OutOfSample)
+	}
+	o := optimizeOpts{metric: MetricReturn}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	// Windows are sliced by index so bars need to be aligned across symbols
+	aligned := align(bars)
+	n := 0
+	for _, bs := range aligned {
+		n = len(bs)
+	}
+	if n < w.InSample+1 {
+		return nil, fmt.Errorf("%w: %d bars aren't enough for an in-sample window of %d", ErrNoData, n, w.InSample)
+	}
+
+	warm := o
+	warm.options = append(slices.Clip(o.options), WithWarmup(w.InSample))
+
+	res := &WalkForwardResult{}
+	for k := 0; ; k++ {
+		isStart := k * w.OutOfSample
+		if w.Anchored {
+			isStart = 0
+		}
+		isEnd := w.InSample + k*w.OutOfSample
+		if isEnd >= n {
+			break
+		}
+		oosEnd := min(isEnd+w.OutOfSample, n)
+
+		trials, err := Optimize(window(aligned, isStart, isEnd), grid, strategy, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", k, err)
+		}
+		best := trials[0]
+		oos, err := run(window(aligned, isEnd-w.InSample, oosEnd), best.Params, strategy, warm)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", k, err)
+		}
+
+		start, end := oos.Result.Stats.Start, oos.Result.Stats.End
+		res.Windows = append(res.Windows, &Window{
+			InSampleStart:    best.Result.Stats.Start,
+			InSampleEnd:      best.Result.Stats.End,
+			OutOfSampleStart: start,
+			OutOfSampleEnd:   end,
+			InSample:         best,
+			OutOfSample:      oos.Result,
+			Score:            oos.Score,
+		})
+		res.Trades += oos.Result.Stats.Trades
+	}
+	res.stitch()
+
+	return res, nil
+}
+
+// window returns bars between indexes [from, to) of every symbol.
+func window(bars map[string][]Bar, from, to int) map[string][]Bar {
+	res := make(map[string][]Bar, len(bars))
+	for symbol, bs := range bars {
+		res[symbol] = bs[from:to]
+	}
+	return res
+}
+
+// stitch chains the out-of-sample equity curves scaling each of them to start
+// where the previous one ended and computes the drawdowns of the whole curve.
+func (r *WalkForwardResult) stitch() {
+	r.Equity = nil
+	scale, peak := 1.0, 0.0
+	for _, w := range r.Windows {
+		curve := w.OutOfSample.Equity
+		if len(r.Equity) > 0 && curve[0].Equity > 0 {
+			scale = r.Equity[len(r.Equity)-1].Equity / curve[0].Equity
+		}
+		for _, p := range curve {
+			equity := p.Equity * scale
+			peak = max(peak, equity)
+			dd := 0.0
+			if peak > 0 {
+				dd = (equity/peak - 1) * 100
+			}
+			r.Equity = append(r.Equity, EquityPoint{Time: p.Time, Equity: equity, DrawdownPct: dd})
+			r.MaxDrawdownPct = min(r.MaxDrawdownPct, dd)
+		}
+	}
+	if len(r.Equity) > 0 {
+		first, last := r.Equity[0].Equity, r.Equity[len(r.Equity)-1].Equity
+		r.ReturnPct = (last - first) / first * 100
+	}
+}
+
+// Summary writes the parameters and scores of each window followed by the stats
+// of the stitched out-of-sample equity.
+func (r *WalkForwardResult) Summary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Window\tIn-Sample\tOut-of-Sample\tParams\tIS Score\tOOS Score\tOOS Return\tOOS Trades")
+	for i, win := range r.Windows {
+		fmt.Fprintf(tw, "%d\t%s - %s\t%s - %s\t%s\t%f\t%f\t%f%%\t%d\n",
+			i,
+			win.InSampleStart.Format(time.DateTime),
+			win.InSampleEnd.Format(time.DateTime),
+			win.OutOfSampleStart.Format(time.DateTime),
+			win.OutOfSampleEnd.Format(time.DateTime),
+			win.InSample.Params,
+			win.InSample.Score,
+			win.Score,
+			win.OutOfSample.Stats.ReturnPct,
+			win.OutOfSample.Stats.Trades,
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nOut-of-Sample Return: %f%%\nOut-of-Sample Max Drawdown: %f%%\nOut-of-Sample Trades: %d\n", r.ReturnPct, r.MaxDrawdownPct, r.Trades)
+	return err
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/data"
+	"github.com/pedropmedina/maximus/strategies"
+)
+
+func runCmd(cfg Config) error {
+	def, err := strategy(cfg)
+	if err != nil {
+		return err
+	}
+	bars, err := loadBars(cfg)
+	if err != nil {
+		return err
+	}
+	bt, err := backtest.New(bars, cfg.options()...)
+	if err != nil {
+		return err
+	}
+	res, err := bt.Strategy(cfg.Strategy, def.New(cfg.Params)).Run()
+	if err != nil {
+		return err
+	}
+	if err := res.Summary(os.Stdout); err != nil {
+		return err
+	}
+
+	o := cfg.Output
+	for _, out := range []struct {
+		path      string
+		csv, json func(w io.Writer) error
+	}{
+		{o.Trades, res.WriteTradesCSV, res.WriteTradesJSON},
+		{o.Orders, res.WriteOrdersCSV, res.WriteOrdersJSON},
+		{o.Equity, res.WriteEquityCSV, res.WriteEquityJSON},
+	} {
+		if out.path == "" {
+			continue
+		}
+		write := out.csv
+		if strings.EqualFold(filepath.Ext(out.path), ".json") {
+			write = out.json
+		}
+		if err := writeFile(out.path, write); err != nil {
+			return err
+		}
+	}
+	if o.Report != "" {
+		return writeFile(o.Report, func(w io.Writer) error { return res.WriteHTML(w) })
+	}
+	return nil
+}
+
+func optimizeCmd(cfg Config) error {
+	def, err := strategy(cfg)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Grid) == 0 {
+		return errors.New("no grid given")
+	}
+	metric, ok := backtest.Metrics[cfg.Metric]
+	if !ok {
+		return fmt.Errorf("unknown metric %q, expected one of: %s", cfg.Metric, strings.Join(metricNames(), ", "))
+	}
+	bars, err := loadBars(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Parameters not in the grid are fixed to the given ones
+	strategy := func(p backtest.Params) func(s *backtest.Strategy) {
+		params := make(backtest.Params, len(cfg.Params)+len(p))
+		for k, v := range cfg.Params {
+			params[k] = v
+		}
+		for k, v := range p {
+			params[k] = v
+		}
+		return def.New(params)
+	}
+	opts := []backtest.OptimizeOption{backtest.WithOptions(cfg.options()...), backtest.WithMetric(metric)}
+	if cfg.Workers != 0 {
+		opts = append(opts, backtest.WithWorkers(cfg.Workers))
+	}
+	trials, err := backtest.Optimize(bars, cfg.Grid, strategy, opts...)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "#\tParams\t%s\tReturn [%%]\tMax. Drawdown [%%]\t# Trades\n", cfg.Metric)
+	for i, t := range trials[:min(len(trials), max(cfg.Top, 1))] {
+		st := t.Result.Stats
+		fmt.Fprintf(tw, "%d\t%s\t%.4f\t%.2f\t%.2f\t%d\n", i+1, t.Params, t.Score, st.ReturnPct, st.MaxDrawdownPct, st.Trades)
+	}
+	return tw.Flush()
+}
+
+func fetchCmd(cfg Config) error {
+	req, err := cfg.request()
+	if err != nil {
+		return err
+	}
+	bars, err := alpacaFeed(cfg).Bars(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Output.Dir, 0o755); err != nil {
+		return err
+	}
+	for _, symbol := range req.Symbols {
+		path := filepath.Join(cfg.Output.Dir, data.FileName(symbol, ".csv"))
+		if err := writeFile(path, func(w io.Writer) error { return data.WriteCSV(w, bars[symbol]) }); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d bars written to %s\n", symbol, len(bars[symbol]), path)
+	}
+	return nil
+}
+
+// strategy looks up the config's strategy.
+func strategy(cfg Config) (strategies.Definition, error) {
+	def, ok := strategies.Lookup(cfg.Strategy)
+	if !ok {
+		return def, fmt.Errorf("unknown strategy %q, expected one of: %s", cfg.Strategy, strings.Join(strategies.Names(), ", "))
+	}
+	return def, nil
+}
+
+// loadBars loads the bars requested by the config from its source.
+func loadBars(cfg Config) (map[string][]backtest.Bar, error) {
+	req, err := cfg.request()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := cfg.fileOpts()
+	if err != nil {
+		return nil, err
+	}
+	var feed backtest.DataFeed
+	switch cfg.Source {
+	case "alpaca":
+		feed = alpacaFeed(cfg)
+	case "csv":
+		feed = data.CSVFeed{Dir: cfg.DataDir, Opts: opts}
+	case "parquet":
+		feed = data.ParquetFeed{Dir: cfg.DataDir, Opts: opts}
+	default:
+		return nil, fmt.Errorf("unknown source %q, expected one of: alpaca, csv, parquet", cfg.Source)
+	}
+	return feed.Bars(context.Background(), req)
+}
+
+// alpacaFeed returns a feed pulling bars from alpaca with credentials from the
+// environment. Bars are cached on disk so we only hit the API once per request.
+func alpacaFeed(cfg Config) backtest.DataFeed {
+	c := marketdata.NewClient(marketdata.ClientOpts{
+		BaseURL:   os.Getenv("APCA_API_DATA_URL"),
+		APIKey:    os.Getenv("APCA_API_KEY_ID"),
+		APISecret: os.Getenv("APCA_API_SECRET_KEY"),
+	})
+	return &data.Fetcher{
+		Feed:     data.NewAlpacaFeed(c),
+		CacheDir: cfg.CacheDir,
+		Chunk:    7 * 24 * time.Hour,
+	}
+}
+
+// writeFile creates the file at path and writes to it with write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// metricNames returns the names of the metrics runs can be ranked by sorted.
+func metricNames() []string {
+	names := make([]string, 0, len(backtest.Metrics))
+	for name := range backtest.Metrics {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, cmp.Compare[string])
+	return names
+}
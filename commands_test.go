@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFetchThenRun checks bars written by `fetch` are read back by `run` from
+// files including symbols with slashes.
+func TestFetchThenRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type bar struct {
+			T time.Time `json:"t"`
+			O float64   `json:"o"`
+			H float64   `json:"h"`
+			L float64   `json:"l"`
+			C float64   `json:"c"`
+			V uint64    `json:"v"`
+		}
+		bars := make(map[string][]bar)
+		for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+			for d := 2; d <= 5; d++ {
+				bars[symbol] = append(bars[symbol], bar{T: time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC), O: 10, H: 11, L: 9, C: 10, V: 100})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"bars": bars, "next_page_token": nil})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("APCA_API_DATA_URL", srv.URL)
+	t.Setenv("APCA_API_KEY_ID", "key")
+	t.Setenv("APCA_API_SECRET_KEY", "secret")
+
+	cfg := defaultConfig()
+	cfg.Symbols = []string{"SPY", "BRK/B"}
+	cfg.Start, cfg.End = "2024-01-02", "2024-01-05"
+	cfg.CacheDir = t.TempDir()
+	cfg.Output.Dir = t.TempDir()
+	if err := fetchCmd(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Output.Dir, "BRK%2FB.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Source = "csv"
+	cfg.DataDir = cfg.Output.Dir
+	bars, err := loadBars(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, symbol := range cfg.Symbols {
+		if got := len(bars[symbol]); got != 4 {
+			t.Errorf("%s: got %d bars, want 4", symbol, got)
+		}
+	}
+}
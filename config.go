@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/data"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds everything needed to run a command. It's loaded from a YAML or JSON
+// file given with `-config` and flags take precedence over it.
+type Config struct {
+	Symbols []string `yaml:"symbols" json:"symbols"`
+	// TimeFrame of bars e.g. 5min. Bars from alpaca default to daily ones while the
+	// time frame of csv and parquet files is inferred from their bars when empty.
+	TimeFrame string `yaml:"timeframe" json:"timeframe"`
+	// Start and end dates in "2006-01-02" or RFC 3339 format. Both inclusive.
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+	// Source of bars: alpaca, csv or parquet.
+	Source string `yaml:"source" json:"source"`
+	// DataDir holds a file per symbol e.g. SPY.csv for csv and parquet sources.
+	DataDir string `yaml:"data_dir" json:"data_dir"`
+	// CacheDir caches bars fetched from alpaca. Empty disables caching.
+	CacheDir string `yaml:"cache_dir" json:"cache_dir"`
+	// Files sets how csv and parquet files are read.
+	Files FileConfig `yaml:"files" json:"files"`
+
+	Strategy string          `yaml:"strategy" json:"strategy"`
+	Params   backtest.Params `yaml:"params" json:"params"`
+	Broker   BrokerConfig    `yaml:"broker" json:"broker"`
+
+	// Grid of parameters to optimize. Values are either lists or "start:end:step"
+	// ranges on the command line.
+	Grid    backtest.Grid `yaml:"grid" json:"grid"`
+	Metric  string        `yaml:"metric" json:"metric"`
+	Workers int           `yaml:"workers" json:"workers"`
+	Top     int           `yaml:"top" json:"top"`
+
+	Output OutputConfig `yaml:"output" json:"output"`
+}
+
+// BrokerConfig maps to backtest options. Zero values keep the backtest's defaults.
+type BrokerConfig struct {
+	Cash              float64 `yaml:"cash" json:"cash"`
+	OrderSize         float64 `yaml:"order_size" json:"order_size"`
+	Margin            float64 `yaml:"margin" json:"margin"`
+	MaintenanceMargin float64 `yaml:"maintenance_margin" json:"maintenance_margin"`
+	// Commission and slippage are fractions of the fill's price e.g. 0.001.
+	Commission     float64 `yaml:"commission" json:"commission"`
+	Slippage       float64 `yaml:"slippage" json:"slippage"`
+	TradeOnClose   bool    `yaml:"trade_on_close" json:"trade_on_close"`
+	ExclusiveOrder bool    `yaml:"exclusive_order" json:"exclusive_order"`
+	Fractionable   bool    `yaml:"fractionable" json:"fractionable"`
+	RiskFreeRate   float64 `yaml:"risk_free_rate" json:"risk_free_rate"`
+}
+
+// FileConfig maps to `data.Opts`. Zero values keep the defaults.
+type FileConfig struct {
+	// Columns maps bar fields to column names e.g. close: adj_close. Fields are
+	// symbol, timestamp, open, high, low, close, volume, vwap and trade_count.
+	Columns map[string]string `yaml:"columns" json:"columns"`
+	// TimeFormat is a `time.Parse` layout or one of unix, unix_ms, unix_us and
+	// unix_ns.
+	TimeFormat string `yaml:"time_format" json:"time_format"`
+	// TimeZone of timestamps without one e.g. America/New_York.
+	TimeZone string `yaml:"time_zone" json:"time_zone"`
+	// Comma is the csv field delimiter.
+	Comma string `yaml:"comma" json:"comma"`
+}
+
+// OutputConfig sets where results are written to. Exports are written as JSON
+// when the path ends in .json and as CSV otherwise.
+type OutputConfig struct {
+	Trades string `yaml:"trades" json:"trades"`
+	Orders string `yaml:"orders" json:"orders"`
+	Equity string `yaml:"equity" json:"equity"`
+	Report string `yaml:"report" json:"report"`
+	// Dir bars are written to by `fetch`.
+	Dir string `yaml:"dir" json:"dir"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Source:   "alpaca",
+		CacheDir: filepath.Join(".cache", "bars"),
+		Strategy: "close_over_sma",
+		Metric:   "return",
+		Top:      10,
+		Output:   OutputConfig{Dir: "."},
+	}
+}
+
+// loadConfig reads the YAML or JSON file at path into cfg.
+func loadConfig(path string, cfg *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, cfg)
+	} else {
+		err = yaml.Unmarshal(b, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseConfig parses the command's flags on top of the config file if any.
+// Flags are parsed twice when there's a config file so that the ones set
+// explicitly override the file's values.
+func parseConfig(name string, args []string) (Config, error) {
+	cfg := defaultConfig()
+	var path string
+	fs := newFlagSet(name, &cfg, &path)
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	if path == "" {
+		return cfg, nil
+	}
+
+	cfg = defaultConfig()
+	if err := loadConfig(path, &cfg); err != nil {
+		return cfg, err
+	}
+	if err := newFlagSet(name, &cfg, &path).Parse(args); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func newFlagSet(name string, cfg *Config, path *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(path, "config", *path, "YAML or JSON config file; flags take precedence")
+	fs.Var((*listFlag)(&cfg.Symbols), "symbols", "comma separated symbols e.g. SPY,QQQ")
+	fs.StringVar(&cfg.TimeFrame, "timeframe", cfg.TimeFrame, "bars' time frame e.g. 5min, 1hour, 1day; defaults to 1day for alpaca")
+	fs.StringVar(&cfg.Start, "start", cfg.Start, "start date e.g. 2024-02-10")
+	fs.StringVar(&cfg.End, "end", cfg.End, "end date e.g. 2024-02-21")
+	fs.StringVar(&cfg.Source, "source", cfg.Source, "source of bars: alpaca, csv or parquet")
+	fs.StringVar(&cfg.DataDir, "data-dir", cfg.DataDir, "directory with a file per symbol for csv and parquet sources")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir, "directory alpaca bars are cached in; empty disables caching")
+
+	f := &cfg.Files
+	fs.Var((*columnsFlag)(&f.Columns), "column", "column of a bar field in csv and parquet files as field=column e.g. close=adj_close; repeatable")
+	fs.StringVar(&f.TimeFormat, "time-format", f.TimeFormat, "timestamp layout of csv and parquet files or unix, unix_ms, unix_us, unix_ns")
+	fs.StringVar(&f.TimeZone, "time-zone", f.TimeZone, "time zone of csv and parquet timestamps without one e.g. America/New_York")
+	fs.StringVar(&f.Comma, "comma", f.Comma, "csv field delimiter")
+
+	fs.StringVar(&cfg.Strategy, "strategy", cfg.Strategy, "strategy name")
+	fs.Var((*paramsFlag)(&cfg.Params), "param", "strategy parameter as name=value; repeatable")
+
+	b := &cfg.Broker
+	fs.Float64Var(&b.Cash, "cash", b.Cash, "starting cash")
+	fs.Float64Var(&b.OrderSize, "order-size", b.OrderSize, "default order size as a fraction of buying power")
+	fs.Float64Var(&b.Margin, "margin", b.Margin, "initial margin e.g. 0.5 for 2:1 leverage")
+	fs.Float64Var(&b.MaintenanceMargin, "maintenance-margin", b.MaintenanceMargin, "maintenance margin")
+	fs.Float64Var(&b.Commission, "commission", b.Commission, "commission as a fraction of notional e.g. 0.001")
+	fs.Float64Var(&b.Slippage, "slippage", b.Slippage, "slippage as a fraction of price e.g. 0.0005")
+	fs.BoolVar(&b.TradeOnClose, "trade-on-close", b.TradeOnClose, "fill market orders at the bar's close")
+	fs.BoolVar(&b.ExclusiveOrder, "exclusive-order", b.ExclusiveOrder, "keep a single trade open per symbol")
+	fs.BoolVar(&b.Fractionable, "fractionable", b.Fractionable, "treat order sizes as fractional units")
+	fs.Float64Var(&b.RiskFreeRate, "risk-free-rate", b.RiskFreeRate, "annual risk free rate e.g. 0.04")
+
+	fs.Var((*gridFlag)(&cfg.Grid), "grid", "parameter values to optimize as name=start:end:step or name=v1,v2; repeatable")
+	fs.StringVar(&cfg.Metric, "metric", cfg.Metric, "metric to rank runs by: "+strings.Join(metricNames(), ", "))
+	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "concurrent runs; defaults to the number of CPUs")
+	fs.IntVar(&cfg.Top, "top", cfg.Top, "number of runs to print")
+
+	o := &cfg.Output
+	fs.StringVar(&o.Trades, "trades", o.Trades, "write the trade log to this .csv or .json file")
+	fs.StringVar(&o.Orders, "orders", o.Orders, "write the order log to this .csv or .json file")
+	fs.StringVar(&o.Equity, "equity", o.Equity, "write the equity curve to this .csv or .json file")
+	fs.StringVar(&o.Report, "report", o.Report, "write an HTML report to this file")
+	fs.StringVar(&o.Dir, "out", o.Dir, "directory fetched bars are written to as <symbol>.csv with the symbol escaped e.g. BRK%2FB.csv")
+	return fs
+}
+
+// request returns the bars request described by the config.
+func (c Config) request() (backtest.BarsRequest, error) {
+	req := backtest.BarsRequest{Symbols: c.Symbols}
+	if len(c.Symbols) == 0 {
+		return req, errors.New("no symbols given")
+	}
+	var err error
+	if req.TimeFrame, err = c.timeFrame(); err != nil {
+		return req, err
+	}
+	if req.Start, err = parseDate(c.Start, false); err != nil {
+		return req, fmt.Errorf("start: %w", err)
+	}
+	if req.End, err = parseDate(c.End, true); err != nil {
+		return req, fmt.Errorf("end: %w", err)
+	}
+	return req, nil
+}
+
+// timeFrame returns the configured time frame or daily bars when not set.
+func (c Config) timeFrame() (backtest.TimeFrame, error) {
+	if c.TimeFrame == "" {
+		return backtest.TimeFrame{N: 1, Unit: backtest.Day}, nil
+	}
+	return backtest.ParseTimeFrame(c.TimeFrame)
+}
+
+// fileOpts returns the options csv and parquet files are read with.
+func (c Config) fileOpts() (data.Opts, error) {
+	f := c.Files
+	opts := data.Opts{TimeFormat: f.TimeFormat}
+	cols := &opts.Columns
+	fields := map[string]*string{
+		"symbol":      &cols.Symbol,
+		"timestamp":   &cols.Timestamp,
+		"open":        &cols.Open,
+		"high":        &cols.High,
+		"low":         &cols.Low,
+		"close":       &cols.Close,
+		"volume":      &cols.Volume,
+		"vwap":        &cols.VWAP,
+		"trade_count": &cols.TradeCount,
+	}
+	for field, col := range f.Columns {
+		p, ok := fields[strings.ToLower(field)]
+		if !ok {
+			return opts, fmt.Errorf("unknown column field %q, expected one of: symbol, timestamp, open, high, low, close, volume, vwap, trade_count", field)
+		}
+		*p = col
+	}
+	if f.TimeZone != "" {
+		loc, err := time.LoadLocation(f.TimeZone)
+		if err != nil {
+			return opts, fmt.Errorf("time zone: %w", err)
+		}
+		opts.Location = loc
+	}
+	if f.Comma != "" {
+		r := []rune(f.Comma)
+		if len(r) != 1 {
+			return opts, fmt.Errorf("comma: expected a single character, got %q", f.Comma)
+		}
+		opts.Comma = r[0]
+	}
+	return opts, nil
+}
+
+// parseDate parses a date or RFC 3339 timestamp. Dates are the start of the day
+// in UTC or its last instant when end is true so that ranges include them.
+func parseDate(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// options returns the backtest options described by the config.
+func (c Config) options() []backtest.Option {
+	b := c.Broker
+	var opts []backtest.Option
+	if b.Cash != 0 {
+		opts = append(opts, backtest.WithCash(b.Cash))
+	}
+	if b.OrderSize != 0 {
+		opts = append(opts, backtest.WithOrderSize(b.OrderSize))
+	}
+	if b.Margin != 0 {
+		opts = append(opts, backtest.WithMargin(b.Margin))
+	}
+	if b.MaintenanceMargin != 0 {
+		opts = append(opts, backtest.WithMaintenanceMargin(b.MaintenanceMargin))
+	}
+	if b.Commission != 0 {
+		opts = append(opts, backtest.WithCommission(backtest.PercentCommission(b.Commission)))
+	}
+	if b.Slippage != 0 {
+		opts = append(opts, backtest.WithSlippage(backtest.PercentSlippage(b.Slippage)))
+	}
+	if b.RiskFreeRate != 0 {
+		opts = append(opts, backtest.WithRiskFreeRate(b.RiskFreeRate))
+	}
+	// Only alpaca bars are known to be in the requested time frame. Files are left
+	// for the backtest to infer so that annualisation follows their actual bars.
+	if c.TimeFrame != "" || c.Source == "alpaca" {
+		if tf, err := c.timeFrame(); err == nil {
+			opts = append(opts, backtest.WithTimeFrame(tf))
+		}
+	}
+	return append(opts,
+		backtest.WithTradeOnClose(b.TradeOnClose),
+		backtest.WithExclusiveOrder(b.ExclusiveOrder),
+		backtest.WithFractionable(b.Fractionable),
+	)
+}
+
+// listFlag is a comma separated list of values.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
+
+// columnsFlag is a repeatable field=column flag.
+type columnsFlag map[string]string
+
+func (f *columnsFlag) String() string {
+	var s []string
+	for field, col := range *f {
+		s = append(s, field+"="+col)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f *columnsFlag) Set(s string) error {
+	field, col, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected field=column, got %q", s)
+	}
+	if *f == nil {
+		*f = columnsFlag{}
+	}
+	(*f)[strings.TrimSpace(field)] = strings.TrimSpace(col)
+	return nil
+}
+
+// paramsFlag is a repeatable name=value flag.
+type paramsFlag backtest.Params
+
+func (f *paramsFlag) String() string {
+	return backtest.Params(*f).String()
+}
+
+func (f *paramsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	if *f == nil {
+		*f = paramsFlag{}
+	}
+	(*f)[strings.TrimSpace(name)] = v
+	return nil
+}
+
+// gridFlag is a repeatable name=start:end:step or name=v1,v2,... flag.
+type gridFlag backtest.Grid
+
+func (f *gridFlag) String() string {
+	var s []string
+	for name, values := range *f {
+		s = append(s, fmt.Sprintf("%s=%v", name, values))
+	}
+	return strings.Join(s, " ")
+}
+
+func (f *gridFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected name=start:end:step or name=v1,v2, got %q", s)
+	}
+	var values []float64
+	if parts := strings.Split(value, ":"); len(parts) == 3 {
+		var r [3]float64
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return err
+			}
+			r[i] = v
+		}
+		if values = backtest.Range(r[0], r[1], r[2]); len(values) == 0 {
+			return fmt.Errorf("empty range %q", value)
+		}
+	} else {
+		for _, p := range strings.Split(value, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+		}
+	}
+	if *f == nil {
+		*f = gridFlag{}
+	}
+	(*f)[strings.TrimSpace(name)] = values
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/data"
+)
+
+func TestParseConfigTimeFrame(t *testing.T) {
+	cfg, err := parseConfig("run", []string{"-source", "csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Left empty so that the backtest infers it from the files' bars
+	if cfg.TimeFrame != "" {
+		t.Errorf("got time frame %q, want none", cfg.TimeFrame)
+	}
+	tf, err := cfg.timeFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tf.String() != "1day" {
+		t.Errorf("got request time frame %s, want 1day", tf)
+	}
+}
+
+func TestLoadBarsFileOpts(t *testing.T) {
+	dir := t.TempDir()
+	csv := "time;o;h;l;adj_close\n2024-01-02 09:30:00;10;12;9;11\n2024-01-02 10:30:00;11;13;10;12\n"
+	if err := os.WriteFile(filepath.Join(dir, "X.csv"), []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	cfg.Symbols = []string{"X"}
+	cfg.Source = "csv"
+	cfg.DataDir = dir
+	cfg.Files = FileConfig{
+		Columns:  map[string]string{"timestamp": "time", "open": "o", "high": "h", "low": "l", "close": "adj_close"},
+		TimeZone: "America/New_York",
+		Comma:    ";",
+	}
+
+	bars, err := loadBars(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars["X"]) != 2 {
+		t.Fatalf("got %d bars, want 2", len(bars["X"]))
+	}
+	if want := time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC); !bars["X"][0].Timestamp.Equal(want) {
+		t.Errorf("timestamp: got %s, want %s", bars["X"][0].Timestamp, want)
+	}
+	if bars["X"][1].Close != 12 {
+		t.Errorf("close: got %f, want 12", bars["X"][1].Close)
+	}
+
+	cfg.Files.Columns = nil
+	if _, err := loadBars(cfg); !errors.Is(err, data.ErrMissingColumn) {
+		t.Errorf("got %v, want %v", err, data.ErrMissingColumn)
+	}
+	cfg.Files.Columns = map[string]string{"adj_close": "close"}
+	if _, err := loadBars(cfg); err == nil {
+		t.Error("got no error for an unknown field")
+	}
+}
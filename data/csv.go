@@ -0,0 +1,163 @@
+package data
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// LoadCSV reads bars keyed by symbol from the CSV file at path. See `ReadCSV`.
+func LoadCSV(path string, opts Opts) (map[string][]backtest.Bar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if opts.Symbol == "" {
+		opts.Symbol = fileSymbol(path)
+	}
+	return ReadCSV(f, opts)
+}
+
+// ReadCSV reads bars keyed by symbol from r. The first record must be a header
+// with the column names configured in `opts.Columns`.
+func ReadCSV(r io.Reader, opts Opts) (map[string][]backtest.Bar, error) {
+	opts = opts.withDefaults()
+
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Comma
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	// col returns the index of the given column or -1 when not found.
+	col := func(name string) int {
+		if i, ok := index[strings.ToLower(name)]; ok {
+			return i
+		}
+		return -1
+	}
+
+	c := opts.Columns
+	for _, name := range []string{c.Timestamp, c.Open, c.High, c.Low, c.Close} {
+		if col(name) < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrMissingColumn, name)
+		}
+	}
+	symbolI, tsI, volumeI, countI := col(c.Symbol), col(c.Timestamp), col(c.Volume), col(c.TradeCount)
+
+	bars := make(map[string][]backtest.Bar)
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var bar backtest.Bar
+		if bar.Timestamp, err = parseTime(record[tsI], opts); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		// Prices are required while VWAP is optional and empty values default to 0
+		for _, f := range []struct {
+			name     string
+			dst      *float64
+			required bool
+		}{
+			{c.Open, &bar.Open, true},
+			{c.High, &bar.High, true},
+			{c.Low, &bar.Low, true},
+			{c.Close, &bar.Close, true},
+			{c.VWAP, &bar.VWAP, false},
+		} {
+			i := col(f.name)
+			if i < 0 {
+				continue
+			}
+			if f.required && strings.TrimSpace(record[i]) == "" {
+				return nil, fmt.Errorf("line %d: %w in column %q", line, ErrMissingValue, f.name)
+			}
+			if *f.dst, err = parseFloat(record[i]); err != nil {
+				return nil, fmt.Errorf("line %d: column %q: %w", line, f.name, err)
+			}
+		}
+		if volumeI >= 0 {
+			volume, err := parseFloat(record[volumeI])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: column %q: %w", line, c.Volume, err)
+			}
+			bar.Volume = volume
+		}
+		if countI >= 0 {
+			count, err := parseFloat(record[countI])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: column %q: %w", line, c.TradeCount, err)
+			}
+			bar.TradeCount = uint64(math.Round(count))
+		}
+
+		var symbol string
+		if symbolI >= 0 {
+			symbol = strings.TrimSpace(record[symbolI])
+		}
+		if err := appendBar(bars, symbol, bar, opts); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return bars, nil
+}
+
+// parseFloat parses s treating empty values as 0. Required columns are checked
+// for empty values beforehand.
+func parseFloat(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// WriteCSV writes bars to w as CSV with a header row using the default column
+// names so that the output can be read back with `ReadCSV`. Timestamps are
+// written in RFC 3339.
+func WriteCSV(w io.Writer, bars []backtest.Bar) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "open", "high", "low", "close", "volume", "vwap", "trade_count"}); err != nil {
+		return err
+	}
+	format := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, b := range bars {
+		if err := cw.Write([]string{
+			b.Timestamp.Format(time.RFC3339),
+			format(b.Open),
+			format(b.High),
+			format(b.Low),
+			format(b.Close),
+			format(b.Volume),
+			format(b.VWAP),
+			strconv.FormatUint(b.TradeCount, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,162 @@
+package data_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/data"
+)
+
+func TestReadCSVEmptyValues(t *testing.T) {
+	const header = "timestamp,open,high,low,close,volume,vwap\n"
+	tests := []struct {
+		name string
+		rows string
+		err  error
+		msg  string
+	}{
+		{
+			name: "optional columns",
+			rows: "2024-01-02,1,2,0.5,1.5,,\n",
+		},
+		{
+			name: "empty close",
+			rows: "2024-01-02,1,2,0.5,1.5,100,1\n2024-01-03,1,2,0.5, ,100,1\n",
+			err:  data.ErrMissingValue,
+			msg:  `line 3: missing value in column "close"`,
+		},
+		{
+			name: "empty open",
+			rows: "2024-01-02,,2,0.5,1.5,100,1\n",
+			err:  data.ErrMissingValue,
+			msg:  `line 2: missing value in column "open"`,
+		},
+		{
+			name: "invalid volume",
+			rows: "2024-01-02,1,2,0.5,1.5,lots,1\n",
+			msg:  `line 2: column "volume"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars, err := data.ReadCSV(strings.NewReader(header+tt.rows), data.Opts{Symbol: "X"})
+			if tt.msg == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b := bars["X"][0]; b.Volume != 0 || b.VWAP != 0 || b.Close != 1.5 {
+					t.Errorf("got %+v, want close 1.5 and no volume nor vwap", b)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("got %q, want it to contain %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		csv  string
+		opts data.Opts
+		want map[string][]backtest.Bar
+		err  error
+	}{
+		{
+			name: "default columns",
+			csv:  "Timestamp,Open,High,Low,Close,Volume,VWAP,Trade_Count\n2024-01-02,1,2,0.5,1.5,100,1.2,7\n",
+			opts: data.Opts{Symbol: "X"},
+			want: map[string][]backtest.Bar{"X": {{Timestamp: day(2), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100, VWAP: 1.2, TradeCount: 7}}},
+		},
+		{
+			name: "symbol column",
+			csv:  "symbol,timestamp,open,high,low,close\nA,2024-01-02,1,1,1,1\nB,2024-01-02,2,2,2,2\nA,2024-01-03,3,3,3,3\n",
+			want: map[string][]backtest.Bar{
+				"A": {{Timestamp: day(2), Open: 1, High: 1, Low: 1, Close: 1}, {Timestamp: day(3), Open: 3, High: 3, Low: 3, Close: 3}},
+				"B": {{Timestamp: day(2), Open: 2, High: 2, Low: 2, Close: 2}},
+			},
+		},
+		{
+			name: "custom columns",
+			csv:  "t;o;h;l;c;v\n1704153600000;1;2;0.5;1.5;100\n",
+			opts: data.Opts{
+				Symbol:     "X",
+				Columns:    data.Columns{Timestamp: "t", Open: "o", High: "h", Low: "l", Close: "c", Volume: "v"},
+				TimeFormat: data.UnixMilli,
+				Comma:      ';',
+			},
+			want: map[string][]backtest.Bar{"X": {{Timestamp: day(2), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100}}},
+		},
+		{
+			name: "location",
+			csv:  "timestamp,open,high,low,close\n2024-01-02 09:30:00,1,1,1,1\n",
+			opts: data.Opts{Symbol: "X", Location: backtest.NYSE.Location},
+			want: map[string][]backtest.Bar{"X": {{Timestamp: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Open: 1, High: 1, Low: 1, Close: 1}}},
+		},
+		{
+			name: "missing column",
+			csv:  "timestamp,open,high,close\n2024-01-02,1,1,1\n",
+			opts: data.Opts{Symbol: "X"},
+			err:  data.ErrMissingColumn,
+		},
+		{
+			name: "missing symbol",
+			csv:  "timestamp,open,high,low,close\n2024-01-02,1,1,1,1\n",
+			err:  data.ErrMissingSymbol,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := data.ReadCSV(strings.NewReader(tt.csv), tt.opts)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			assertBars(t, got, tt.want)
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	bars := []backtest.Bar{
+		{Timestamp: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100, VWAP: 1.25, TradeCount: 7},
+		{Timestamp: time.Date(2024, 1, 2, 14, 35, 0, 0, time.UTC), Open: 1.5, High: 1.5, Low: 1.5, Close: 1.5},
+	}
+	var sb strings.Builder
+	if err := data.WriteCSV(&sb, bars); err != nil {
+		t.Fatal(err)
+	}
+	got, err := data.ReadCSV(strings.NewReader(sb.String()), data.Opts{Symbol: "X"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, got, map[string][]backtest.Bar{"X": bars})
+}
+
+func assertBars(t *testing.T, got, want map[string][]backtest.Bar) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d symbols, want %d", len(got), len(want))
+	}
+	for symbol, bars := range want {
+		if len(got[symbol]) != len(bars) {
+			t.Fatalf("%s: got %d bars, want %d", symbol, len(got[symbol]), len(bars))
+		}
+		for i, b := range bars {
+			if g := got[symbol][i]; !g.Timestamp.Equal(b.Timestamp) || g.Open != b.Open || g.High != b.High || g.Low != b.Low || g.Close != b.Close || g.Volume != b.Volume || g.VWAP != b.VWAP || g.TradeCount != b.TradeCount {
+				t.Errorf("%s %d: got %+v, want %+v", symbol, i, g, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package data loads bars into `backtest.Bar` from market data providers e.g. Alpaca
+// and from files e.g. CSV and Parquet so that backtests can also run offline on
+// vendored datasets.
+package data
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+var (
+	// ErrMissingColumn is returned when a required column isn't found.
+	ErrMissingColumn = errors.New("missing column")
+	// ErrMissingSymbol is returned when bars can't be keyed by symbol.
+	ErrMissingSymbol = errors.New("missing symbol")
+	// ErrMissingValue is returned when a required column has an empty value.
+	ErrMissingValue = errors.New("missing value")
+)
+
+// Timestamp formats besides any layout supported by `time.Parse`.
+const (
+	Unix      = "unix"
+	UnixMilli = "unix_ms"
+	UnixMicro = "unix_us"
+	UnixNano  = "unix_ns"
+)
+
+// Columns maps bar fields to column names. Names are matched case-insensitively.
+type Columns struct {
+	Symbol     string
+	Timestamp  string
+	Open       string
+	High       string
+	Low        string
+	Close      string
+	Volume     string
+	VWAP       string
+	TradeCount string
+}
+
+// Opts configures how files are read. Zero values fall back to defaults.
+type Opts struct {
+	// Symbol bars are keyed by when there's no symbol column. `LoadCSV` and
+	// `LoadParquet` default to the file's name without extension, unescaped. See
+	// `FileName`.
+	Symbol string
+	// Columns default to lowercase field names e.g. "timestamp", "open", "trade_count".
+	// Symbol, volume, vwap and trade count columns are optional.
+	Columns Columns
+	// TimeFormat is either a `time.Parse` layout or one of `Unix`, `UnixMilli`,
+	// `UnixMicro` and `UnixNano`. Defaults to trying RFC 3339, "2006-01-02 15:04:05"
+	// and "2006-01-02" in that order.
+	TimeFormat string
+	// Location of timestamps without time zone. Defaults to UTC.
+	Location *time.Location
+	// Comma is the CSV field delimiter. Defaults to ','.
+	Comma rune
+}
+
+// withDefaults returns a copy of opts with defaults applied.
+func (o Opts) withDefaults() Opts {
+	c := &o.Columns
+	for _, col := range []struct {
+		name *string
+		def  string
+	}{
+		{&c.Symbol, "symbol"},
+		{&c.Timestamp, "timestamp"},
+		{&c.Open, "open"},
+		{&c.High, "high"},
+		{&c.Low, "low"},
+		{&c.Close, "close"},
+		{&c.Volume, "volume"},
+		{&c.VWAP, "vwap"},
+		{&c.TradeCount, "trade_count"},
+	} {
+		if *col.name == "" {
+			*col.name = col.def
+		}
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	return o
+}
+
+var defaultTimeFormats = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// parseTime parses a timestamp given as text according to `opts.TimeFormat`.
+func parseTime(s string, opts Opts) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch opts.TimeFormat {
+	case Unix, UnixMilli, UnixMicro, UnixNano:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return unixTime(n, opts.TimeFormat), nil
+	case "":
+		var err error
+		for _, layout := range defaultTimeFormats {
+			var t time.Time
+			if t, err = time.ParseInLocation(layout, s, opts.Location); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, err
+	default:
+		return time.ParseInLocation(opts.TimeFormat, s, opts.Location)
+	}
+}
+
+// unixTime converts n in the given unix format to `time.Time`.
+func unixTime(n int64, format string) time.Time {
+	switch format {
+	case UnixMilli:
+		return time.UnixMilli(n).UTC()
+	case UnixMicro:
+		return time.UnixMicro(n).UTC()
+	case UnixNano:
+		return time.Unix(0, n).UTC()
+	default:
+		return time.Unix(n, 0).UTC()
+	}
+}
+
+// fileSymbol returns the symbol of the file at path named after it. See `FileName`.
+func fileSymbol(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if symbol, err := url.PathUnescape(name); err == nil {
+		return symbol
+	}
+	return name
+}
+
+// appendBar adds bar to the bars of the given symbol or fallback symbol.
+func appendBar(bars map[string][]backtest.Bar, symbol string, bar backtest.Bar, opts Opts) error {
+	if symbol == "" {
+		symbol = opts.Symbol
+	}
+	if symbol == "" {
+		return fmt.Errorf("%w: no symbol column %q nor default symbol", ErrMissingSymbol, opts.Columns.Symbol)
+	}
+	bars[symbol] = append(bars[symbol], bar)
+	return nil
+}
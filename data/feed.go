@@ -0,0 +1,174 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// Feeds implementing `backtest.DataFeed`.
+var (
+	_ backtest.DataFeed = (*AlpacaFeed)(nil)
+	_ backtest.DataFeed = CSVFeed{}
+	_ backtest.DataFeed = ParquetFeed{}
+	_ backtest.DataFeed = MemoryFeed{}
+)
+
+// AlpacaFeed fetches bars from Alpaca's market data API.
+type AlpacaFeed struct {
+	client *marketdata.Client
+}
+
+// NewAlpacaFeed returns a feed backed by the given Alpaca client.
+func NewAlpacaFeed(client *marketdata.Client) *AlpacaFeed {
+	return &AlpacaFeed{client: client}
+}
+
+func (f *AlpacaFeed) Bars(ctx context.Context, req backtest.BarsRequest) (map[string][]backtest.Bar, error) {
+	// The client doesn't take a context so the best we can do is not starting
+	// the request when it's already done.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tf, err := alpacaTimeFrame(req.TimeFrame)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.client.GetMultiBars(req.Symbols, marketdata.GetBarsRequest{
+		TimeFrame: tf,
+		Start:     req.Start,
+		End:       req.End,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bars := make(map[string][]backtest.Bar, len(res))
+	for symbol, bs := range res {
+		bars[symbol] = make([]backtest.Bar, len(bs))
+		for i, b := range bs {
+			bars[symbol][i] = fromAlpaca(b)
+		}
+	}
+	return bars, nil
+}
+
+// alpacaTimeFrame maps tf to Alpaca's time frame.
+func alpacaTimeFrame(tf backtest.TimeFrame) (marketdata.TimeFrame, error) {
+	unit, ok := map[backtest.TimeFrameUnit]marketdata.TimeFrameUnit{
+		backtest.Minute: marketdata.Min,
+		backtest.Hour:   marketdata.Hour,
+		backtest.Day:    marketdata.Day,
+		backtest.Week:   marketdata.Week,
+		backtest.Month:  marketdata.Month,
+	}[tf.Unit]
+	if !ok || tf.N <= 0 {
+		return marketdata.TimeFrame{}, fmt.Errorf("unsupported time frame %s", tf)
+	}
+	return marketdata.NewTimeFrame(tf.N, unit), nil
+}
+
+// fromAlpaca maps an Alpaca bar to ours.
+func fromAlpaca(b marketdata.Bar) backtest.Bar {
+	return backtest.Bar{
+		Timestamp:  b.Timestamp,
+		Open:       b.Open,
+		High:       b.High,
+		Low:        b.Low,
+		Close:      b.Close,
+		Volume:     float64(b.Volume),
+		VWAP:       b.VWAP,
+		TradeCount: b.TradeCount,
+	}
+}
+
+// CSVFeed reads bars from a CSV file per symbol named <symbol>.csv in `Dir`. See
+// `FileName`. Bars are expected in the request's time frame as no resampling
+// takes place.
+type CSVFeed struct {
+	Dir  string
+	Opts Opts
+}
+
+func (f CSVFeed) Bars(ctx context.Context, req backtest.BarsRequest) (map[string][]backtest.Bar, error) {
+	return loadFiles(ctx, f.Dir, ".csv", req, func(path string, opts Opts) (map[string][]backtest.Bar, error) {
+		return LoadCSV(path, opts)
+	}, f.Opts)
+}
+
+// ParquetFeed reads bars from a Parquet file per symbol named <symbol>.parquet in
+// `Dir`. See `FileName`. Bars are expected in the request's time frame as no
+// resampling takes place.
+type ParquetFeed struct {
+	Dir  string
+	Opts Opts
+}
+
+func (f ParquetFeed) Bars(ctx context.Context, req backtest.BarsRequest) (map[string][]backtest.Bar, error) {
+	return loadFiles(ctx, f.Dir, ".parquet", req, func(path string, opts Opts) (map[string][]backtest.Bar, error) {
+		return LoadParquet(path, opts)
+	}, f.Opts)
+}
+
+// FileName returns the name of the file with the given extension holding bars of
+// symbol. Symbols are escaped as some contain slashes e.g. BRK/B is read from
+// BRK%2FB.csv.
+func FileName(symbol, ext string) string {
+	return url.PathEscape(symbol) + ext
+}
+
+// loadFiles loads the file of each requested symbol from dir with load.
+func loadFiles(
+	ctx context.Context,
+	dir, ext string,
+	req backtest.BarsRequest,
+	load func(path string, opts Opts) (map[string][]backtest.Bar, error),
+	opts Opts,
+) (map[string][]backtest.Bar, error) {
+	bars := make(map[string][]backtest.Bar, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		opts.Symbol = symbol
+		res, err := load(filepath.Join(dir, FileName(symbol, ext)), opts)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSymbol, symbol)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		bars[symbol] = filter(res[symbol], req)
+	}
+	return bars, nil
+}
+
+// MemoryFeed serves bars already in memory keyed by symbol e.g. generated or
+// loaded beforehand.
+type MemoryFeed map[string][]backtest.Bar
+
+func (f MemoryFeed) Bars(ctx context.Context, req backtest.BarsRequest) (map[string][]backtest.Bar, error) {
+	bars := make(map[string][]backtest.Bar, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		bs, ok := f[symbol]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSymbol, symbol)
+		}
+		bars[symbol] = filter(bs, req)
+	}
+	return bars, nil
+}
+
+// filter returns a copy of bars within the requested range.
+func filter(bars []backtest.Bar, req backtest.BarsRequest) []backtest.Bar {
+	return slices.DeleteFunc(slices.Clone(bars), func(b backtest.Bar) bool {
+		return !req.Contains(b.Timestamp)
+	})
+}
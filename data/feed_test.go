@@ -0,0 +1,142 @@
+package data_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/data"
+)
+
+// feedBars are daily bars from 2024-01-01 to 2024-01-05.
+func feedBars() []backtest.Bar {
+	bars := make([]backtest.Bar, 5)
+	for i := range bars {
+		p := float64(100 + i)
+		bars[i] = backtest.Bar{Timestamp: time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC), Open: p, High: p, Low: p, Close: p, Volume: 10}
+	}
+	return bars
+}
+
+// writeFeed writes the bars of each symbol to its file in a temporary directory.
+func writeFeed(t *testing.T, bars map[string][]backtest.Bar) string {
+	t.Helper()
+	dir := t.TempDir()
+	for symbol, bs := range bars {
+		f, err := os.Create(filepath.Join(dir, data.FileName(symbol, ".csv")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := data.WriteCSV(f, bs); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFeeds(t *testing.T) {
+	bars := map[string][]backtest.Bar{"A": feedBars(), "B": feedBars()}
+	feeds := map[string]backtest.DataFeed{
+		"memory": data.MemoryFeed(bars),
+		"csv":    data.CSVFeed{Dir: writeFeed(t, bars)},
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		req  backtest.BarsRequest
+		want map[string][]backtest.Bar
+		err  error
+	}{
+		{
+			name: "all bars",
+			req:  backtest.BarsRequest{Symbols: []string{"A", "B"}},
+			want: bars,
+		},
+		{
+			name: "range",
+			req:  backtest.BarsRequest{Symbols: []string{"A"}, Start: day(2), End: day(4)},
+			want: map[string][]backtest.Bar{"A": feedBars()[1:4]},
+		},
+		{
+			name: "open start",
+			req:  backtest.BarsRequest{Symbols: []string{"B"}, End: day(2)},
+			want: map[string][]backtest.Bar{"B": feedBars()[:2]},
+		},
+		{
+			name: "unknown symbol",
+			req:  backtest.BarsRequest{Symbols: []string{"A", "C"}},
+			err:  data.ErrMissingSymbol,
+		},
+	}
+	for name, feed := range feeds {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				got, err := feed.Bars(context.Background(), tt.req)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				assertBars(t, got, tt.want)
+			})
+		}
+	}
+
+	// Filtered bars are a copy of the feed's
+	got, err := feeds["memory"].Bars(context.Background(), backtest.BarsRequest{Symbols: []string{"A"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got["A"][0].Close = 0
+	if bars["A"][0].Close == 0 {
+		t.Error("memory feed's bars were modified through the returned ones")
+	}
+}
+
+func TestCSVFeedCanceled(t *testing.T) {
+	feed := data.CSVFeed{Dir: writeFeed(t, map[string][]backtest.Bar{"A": feedBars()})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := feed.Bars(ctx, backtest.BarsRequest{Symbols: []string{"A"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLoadCSVSymbol(t *testing.T) {
+	dir := writeFeed(t, map[string][]backtest.Bar{"SPY": feedBars()})
+	// Bars are keyed by the file's name unless a symbol is given
+	got, err := data.LoadCSV(filepath.Join(dir, "SPY.csv"), data.Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, got, map[string][]backtest.Bar{"SPY": feedBars()})
+	got, err = data.LoadCSV(filepath.Join(dir, "SPY.csv"), data.Opts{Symbol: "X"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, got, map[string][]backtest.Bar{"X": feedBars()})
+}
+
+func TestCSVFeedEscapedSymbol(t *testing.T) {
+	dir := writeFeed(t, map[string][]backtest.Bar{"BRK/B": feedBars()})
+	if _, err := os.Stat(filepath.Join(dir, "BRK%2FB.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	bars, err := data.CSVFeed{Dir: dir}.Bars(context.Background(), backtest.BarsRequest{Symbols: []string{"BRK/B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, bars, map[string][]backtest.Bar{"BRK/B": feedBars()})
+	// Bars are keyed by the unescaped file's name
+	bars, err = data.LoadCSV(filepath.Join(dir, "BRK%2FB.csv"), data.Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBars(t, bars, map[string][]backtest.Bar{"BRK/B": feedBars()})
+}
@@ -0,0 +1,193 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+var _ backtest.DataFeed = (*Fetcher)(nil)
+
+// Fetcher pulls bars from a feed in date range chunks and caches them on disk keyed
+// by request so that repeated backtests neither hit the provider again nor need
+// network access. It's a `backtest.DataFeed` itself.
+//
+//	f := &data.Fetcher{Feed: data.NewAlpacaFeed(client), CacheDir: ".cache", Chunk: 30 * 24 * time.Hour}
+//	bars, err := f.Bars(ctx, req)
+type Fetcher struct {
+	// Feed bars are pulled from on cache misses.
+	Feed backtest.DataFeed
+	// CacheDir where bars are cached. Caching is disabled when empty.
+	CacheDir string
+	// Chunk splits the requested range into requests covering at most this
+	// duration. Zero requests the whole range at once.
+	Chunk time.Duration
+	// Now tells which bar is still forming and is used as end of requests without
+	// one. Defaults to `time.Now`.
+	Now func() time.Time
+}
+
+// Bars returns bars of each requested symbol from the cache or the feed. Symbols
+// and chunks are cached separately so that requests sharing symbols or part of
+// their range only fetch what's missing. Chunks reaching the current bar aren't
+// cached as it's still forming.
+func (f *Fetcher) Bars(ctx context.Context, req backtest.BarsRequest) (map[string][]backtest.Bar, error) {
+	if f.Feed == nil {
+		return nil, errors.New("fetcher has no feed")
+	}
+	d := req.TimeFrame.Duration()
+	if d <= 0 {
+		return nil, fmt.Errorf("invalid time frame %s", req.TimeFrame)
+	}
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	// Bars are timestamped at their start so the one at the truncated now is the
+	// one still forming.
+	current := now().Truncate(d)
+	// Open ended requests are pinned to the current bar so that the chunks before
+	// it keep hitting the cache.
+	if req.End.IsZero() {
+		req.End = current
+	}
+
+	bars := make(map[string][]backtest.Bar, len(req.Symbols))
+	for _, chunk := range chunks(req, f.Chunk) {
+		var missing []string
+		for _, symbol := range req.Symbols {
+			bs, ok, err := f.read(symbol, chunk)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				bars[symbol] = append(bars[symbol], bs...)
+				continue
+			}
+			missing = append(missing, symbol)
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		chunk.Symbols = missing
+		res, err := f.Feed.Bars(ctx, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s - %s: %w", chunk.Start.Format(time.RFC3339), chunk.End.Format(time.RFC3339), err)
+		}
+		for _, symbol := range missing {
+			bars[symbol] = append(bars[symbol], res[symbol]...)
+			if !chunk.End.Before(current) {
+				continue
+			}
+			if err := f.write(symbol, chunk, res[symbol]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Chunks are inclusive on both ends so bars on their boundaries may be repeated
+	for symbol, bs := range bars {
+		slices.SortStableFunc(bs, func(a, b backtest.Bar) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		bars[symbol] = slices.CompactFunc(bs, func(a, b backtest.Bar) bool {
+			return a.Timestamp.Equal(b.Timestamp)
+		})
+	}
+	return bars, nil
+}
+
+// chunks splits the request's range in consecutive ranges of size d. There's a
+// single chunk when d is 0 or the request has no start.
+func chunks(req backtest.BarsRequest, d time.Duration) []backtest.BarsRequest {
+	if d <= 0 || req.Start.IsZero() {
+		return []backtest.BarsRequest{req}
+	}
+	var res []backtest.BarsRequest
+	for start := req.Start; !start.After(req.End); start = start.Add(d) {
+		chunk := req
+		chunk.Start = start
+		chunk.End = minTime(start.Add(d), req.End)
+		res = append(res, chunk)
+	}
+	return res
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// cachePath returns the file bars of symbol for the chunk req are cached at.
+// Symbols are escaped as some contain slashes e.g. BTC/USD.
+func (f *Fetcher) cachePath(symbol string, req backtest.BarsRequest) string {
+	key := fmt.Sprintf("%s|%s|%s|%s",
+		symbol,
+		req.TimeFrame,
+		req.Start.UTC().Format(time.RFC3339Nano),
+		req.End.UTC().Format(time.RFC3339Nano),
+	)
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.CacheDir, url.PathEscape(symbol)+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// read returns bars of symbol for req from the cache. It reports whether they're
+// cached.
+func (f *Fetcher) read(symbol string, req backtest.BarsRequest) ([]backtest.Bar, bool, error) {
+	if f.CacheDir == "" {
+		return nil, false, nil
+	}
+	b, err := os.ReadFile(f.cachePath(symbol, req))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var bars []backtest.Bar
+	if err := json.Unmarshal(b, &bars); err != nil {
+		// A corrupted entry is just a cache miss
+		return nil, false, nil
+	}
+	return bars, true, nil
+}
+
+// write caches bars of symbol for req. The file is written to a temporary file
+// first so that concurrent readers never see partial entries.
+func (f *Fetcher) write(symbol string, req backtest.BarsRequest, bars []backtest.Bar) error {
+	if f.CacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(f.CacheDir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(bars)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.CacheDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.cachePath(symbol, req))
+}
@@ -0,0 +1,311 @@
+package data_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/data"
+)
+
+// alpaca is a stand-in for Alpaca's bars endpoint serving a daily bar per symbol
+// for every day within the requested range.
+type alpaca struct {
+	*httptest.Server
+	mu sync.Mutex
+	// requests are the symbols of every request received.
+	requests [][]string
+	// fail makes every request fail with a bad request.
+	fail bool
+}
+
+func newAlpaca(t *testing.T) *alpaca {
+	a := &alpaca{}
+	a.Server = httptest.NewServer(http.HandlerFunc(a.bars))
+	t.Cleanup(a.Close)
+	return a
+}
+
+func (a *alpaca) bars(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	q := r.URL.Query()
+	symbols := strings.Split(q.Get("symbols"), ",")
+	a.requests = append(a.requests, symbols)
+	if r.URL.Path != "/v2/stocks/bars" || a.fail {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 42210000, "message": "invalid request"}`))
+		return
+	}
+
+	start, _ := time.Parse(time.RFC3339, q.Get("start"))
+	end, _ := time.Parse(time.RFC3339, q.Get("end"))
+	type bar struct {
+		T time.Time `json:"t"`
+		O float64   `json:"o"`
+		H float64   `json:"h"`
+		L float64   `json:"l"`
+		C float64   `json:"c"`
+		V uint64    `json:"v"`
+	}
+	res := struct {
+		Bars          map[string][]bar `json:"bars"`
+		NextPageToken *string          `json:"next_page_token"`
+	}{Bars: make(map[string][]bar)}
+	for _, symbol := range symbols {
+		for t := start.Truncate(24 * time.Hour); !t.After(end); t = t.AddDate(0, 0, 1) {
+			if t.Before(start) {
+				continue
+			}
+			price := float64(t.Day())
+			res.Bars[symbol] = append(res.Bars[symbol], bar{T: t, O: price, H: price + 1, L: price - 1, C: price, V: 100})
+		}
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// calls returns the number of requests received so far.
+func (a *alpaca) calls() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.requests)
+}
+
+func (a *alpaca) fetcher(t *testing.T) *data.Fetcher {
+	client := marketdata.NewClient(marketdata.ClientOpts{
+		BaseURL:    a.URL,
+		APIKey:     "key",
+		APISecret:  "secret",
+		RetryLimit: 1,
+		RetryDelay: time.Millisecond,
+	})
+	return &data.Fetcher{Feed: data.NewAlpacaFeed(client), CacheDir: t.TempDir()}
+}
+
+func request(symbols ...string) backtest.BarsRequest {
+	return backtest.BarsRequest{
+		Symbols:   symbols,
+		TimeFrame: backtest.NewTimeFrame(1, backtest.Day),
+		Start:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFetcherCache(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	ctx := context.Background()
+
+	miss, err := f.Bars(ctx, request("SPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(miss["SPY"]); got != 10 {
+		t.Fatalf("got %d bars, want 10", got)
+	}
+	if got := a.calls(); got != 1 {
+		t.Fatalf("cache miss: got %d requests, want 1", got)
+	}
+
+	hit, err := f.Bars(ctx, request("SPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.calls(); got != 1 {
+		t.Errorf("cache hit: got %d requests, want 1", got)
+	}
+	if !slices.EqualFunc(hit["SPY"], miss["SPY"], func(a, b backtest.Bar) bool {
+		return a.Timestamp.Equal(b.Timestamp) && a.Close == b.Close && a.Volume == b.Volume
+	}) {
+		t.Errorf("cached bars differ: got %v, want %v", hit["SPY"], miss["SPY"])
+	}
+}
+
+func TestFetcherMultipleSymbols(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	ctx := context.Background()
+
+	// Symbols with slashes are cached in the cache directory itself
+	bars, err := f.Bars(ctx, request("SPY", "BRK/B"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, symbol := range []string{"SPY", "BRK/B"} {
+		if got := len(bars[symbol]); got != 10 {
+			t.Errorf("%s: got %d bars, want 10", symbol, got)
+		}
+	}
+	entries, err := os.ReadDir(f.CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			t.Errorf("unexpected cache directory %s", filepath.Join(f.CacheDir, e.Name()))
+		}
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d cache entries, want 2", len(entries))
+	}
+
+	// Only symbols missing from the cache are fetched
+	bars, err = f.Bars(ctx, request("BRK/B", "QQQ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a.requests[len(a.requests)-1], []string{"QQQ"}; !slices.Equal(got, want) {
+		t.Errorf("got request for %v, want %v", got, want)
+	}
+	if len(bars["BRK/B"]) != 10 || len(bars["QQQ"]) != 10 {
+		t.Errorf("got %d BRK/B and %d QQQ bars, want 10", len(bars["BRK/B"]), len(bars["QQQ"]))
+	}
+}
+
+func TestFetcherChunks(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	f.Chunk = 4 * 24 * time.Hour
+
+	bars, err := f.Bars(context.Background(), request("SPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.calls(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	// Bars on chunk boundaries are only kept once
+	if got := len(bars["SPY"]); got != 10 {
+		t.Errorf("got %d bars, want 10", got)
+	}
+}
+
+func TestFetcherOpenEnded(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	f.Chunk = 4 * 24 * time.Hour
+	now := time.Date(2024, 1, 10, 15, 0, 0, 0, time.UTC)
+	f.Now = func() time.Time { return now }
+
+	// Chunks from the 1st to the 5th, 9th and 10th
+	req := request("SPY")
+	req.End = time.Time{}
+	bars, err := f.Bars(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.calls(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	if got := len(bars["SPY"]); got != 10 {
+		t.Errorf("got %d bars, want 10", got)
+	}
+
+	// The last chunk has the bar of the 10th still forming so it isn't cached
+	now = now.Add(time.Hour)
+	if _, err := f.Bars(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.calls(); got != 4 {
+		t.Errorf("same day: got %d requests, want 4", got)
+	}
+
+	// The next day the last chunk ends on the 11th, still forming, while the first
+	// two keep hitting the cache
+	now = now.AddDate(0, 0, 1)
+	for range 2 {
+		if _, err := f.Bars(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := a.calls(); got != 6 {
+		t.Errorf("next day: got %d requests, want 6", got)
+	}
+}
+
+func TestFetcherCurrentBar(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	req := request("SPY")
+
+	// Requests ending before the current bar are cached
+	f.Now = func() time.Time { return req.End.Add(24 * time.Hour) }
+	for range 2 {
+		if _, err := f.Bars(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := a.calls(); got != 1 {
+		t.Errorf("past: got %d requests, want 1", got)
+	}
+
+	// Requests ending at or after it aren't
+	f.Now = func() time.Time { return req.End.Add(time.Hour) }
+	f.CacheDir = t.TempDir()
+	for range 2 {
+		if _, err := f.Bars(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := a.calls(); got != 3 {
+		t.Errorf("current: got %d requests, want 3", got)
+	}
+}
+
+func TestFetcherTimeFrame(t *testing.T) {
+	a := newAlpaca(t)
+	f := a.fetcher(t)
+	for _, tf := range []backtest.TimeFrame{{}, backtest.NewTimeFrame(0, backtest.Day), backtest.NewTimeFrame(1, "year")} {
+		req := request("SPY")
+		req.TimeFrame = tf
+		req.End = time.Time{}
+		if _, err := f.Bars(context.Background(), req); err == nil {
+			t.Errorf("%s: got no error", tf)
+		}
+	}
+	if got := a.calls(); got != 0 {
+		t.Errorf("got %d requests, want none", got)
+	}
+}
+
+func TestFetcherError(t *testing.T) {
+	a := newAlpaca(t)
+	a.fail = true
+	f := a.fetcher(t)
+
+	if _, err := f.Bars(context.Background(), request("SPY")); err == nil {
+		t.Fatal("got no error")
+	}
+	// Failures aren't cached
+	a.fail = false
+	bars, err := f.Bars(context.Background(), request("SPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(bars["SPY"]); got != 10 {
+		t.Errorf("got %d bars, want 10", got)
+	}
+
+	f.Feed = nil
+	if _, err := f.Bars(context.Background(), request("SPY")); err == nil {
+		t.Error("no feed: got no error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f = a.fetcher(t)
+	if _, err := f.Bars(ctx, request("SPY")); err == nil {
+		t.Error("canceled context: got no error")
+	}
+}
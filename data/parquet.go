@@ -0,0 +1,205 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// LoadParquet reads bars keyed by symbol from the Parquet file at path. See
+// `ReadParquet`.
+func LoadParquet(path string, opts Opts) (map[string][]backtest.Bar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Symbol == "" {
+		opts.Symbol = fileSymbol(path)
+	}
+	return ReadParquet(f, info.Size(), opts)
+}
+
+// ReadParquet reads bars keyed by symbol from a flat Parquet file with the columns
+// configured in `opts.Columns`. Timestamps can be stored as Parquet timestamps,
+// dates, integers (see `opts.TimeFormat` unix formats, defaults to `Unix`) or strings.
+// Null timestamps and prices fail with `ErrMissingValue`.
+func ReadParquet(r io.ReaderAt, size int64, opts Opts) (map[string][]backtest.Bar, error) {
+	opts = opts.withDefaults()
+
+	f, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := f.Schema()
+	index := make(map[string]parquet.LeafColumn)
+	for _, path := range schema.Columns() {
+		if leaf, ok := schema.Lookup(path...); ok {
+			index[strings.ToLower(strings.Join(path, "."))] = leaf
+		}
+	}
+	// col returns the index of the given column or -1 when not found.
+	col := func(name string) int {
+		if leaf, ok := index[strings.ToLower(name)]; ok {
+			return leaf.ColumnIndex
+		}
+		return -1
+	}
+
+	c := opts.Columns
+	for _, name := range []string{c.Timestamp, c.Open, c.High, c.Low, c.Close} {
+		if col(name) < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrMissingColumn, name)
+		}
+	}
+	tsNode := index[strings.ToLower(c.Timestamp)].Node
+
+	pr := parquet.NewReader(f)
+	defer pr.Close()
+
+	bars := make(map[string][]backtest.Bar)
+	rows := make([]parquet.Row, 256)
+	// Rows are numbered from 1 in errors
+	rowN := 0
+	for {
+		n, err := pr.ReadRows(rows)
+		for _, row := range rows[:n] {
+			rowN++
+			values := make(map[int]parquet.Value, len(row))
+			for _, v := range row {
+				values[v.Column()] = v
+			}
+			value := func(name string) (parquet.Value, bool) {
+				v, ok := values[col(name)]
+				return v, ok && !v.IsNull()
+			}
+
+			var bar backtest.Bar
+			v, ok := value(c.Timestamp)
+			if !ok {
+				return nil, fmt.Errorf("row %d: %w in column %q", rowN, ErrMissingValue, c.Timestamp)
+			}
+			if bar.Timestamp, err = parquetTime(v, tsNode, opts); err != nil {
+				return nil, fmt.Errorf("row %d: %w", rowN, err)
+			}
+			for _, f := range []struct {
+				name     string
+				dst      *float64
+				required bool
+			}{
+				{c.Open, &bar.Open, true},
+				{c.High, &bar.High, true},
+				{c.Low, &bar.Low, true},
+				{c.Close, &bar.Close, true},
+				{c.VWAP, &bar.VWAP, false},
+			} {
+				v, ok := value(f.name)
+				if !ok {
+					if f.required {
+						return nil, fmt.Errorf("row %d: %w in column %q", rowN, ErrMissingValue, f.name)
+					}
+					continue
+				}
+				if *f.dst, err = parquetFloat(v); err != nil {
+					return nil, fmt.Errorf("row %d: column %q: %w", rowN, f.name, err)
+				}
+			}
+			if v, ok := value(c.Volume); ok {
+				volume, err := parquetFloat(v)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: column %q: %w", rowN, c.Volume, err)
+				}
+				bar.Volume = volume
+			}
+			if v, ok := value(c.TradeCount); ok {
+				count, err := parquetFloat(v)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: column %q: %w", rowN, c.TradeCount, err)
+				}
+				bar.TradeCount = uint64(math.Round(count))
+			}
+
+			var symbol string
+			if v, ok := value(c.Symbol); ok {
+				symbol = strings.TrimSpace(string(v.ByteArray()))
+			}
+			if err := appendBar(bars, symbol, bar, opts); err != nil {
+				return nil, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bars, nil
+}
+
+// parquetFloat converts numeric and textual values to float64.
+func parquetFloat(v parquet.Value) (float64, error) {
+	switch v.Kind() {
+	case parquet.Int32:
+		return float64(v.Int32()), nil
+	case parquet.Int64:
+		return float64(v.Int64()), nil
+	case parquet.Float:
+		return float64(v.Float()), nil
+	case parquet.Double:
+		return v.Double(), nil
+	case parquet.ByteArray:
+		return parseFloat(string(v.ByteArray()))
+	default:
+		return 0, fmt.Errorf("unsupported type %s", v.Kind())
+	}
+}
+
+// parquetTime converts a timestamp value taking the column's logical type into account.
+func parquetTime(v parquet.Value, node parquet.Node, opts Opts) (time.Time, error) {
+	if lt := node.Type().LogicalType(); lt != nil {
+		switch {
+		case lt.Timestamp != nil:
+			unit := lt.Timestamp.Unit
+			switch {
+			case unit.Millis != nil:
+				return unixTime(v.Int64(), UnixMilli), nil
+			case unit.Micros != nil:
+				return unixTime(v.Int64(), UnixMicro), nil
+			default:
+				return unixTime(v.Int64(), UnixNano), nil
+			}
+		case lt.Date != nil:
+			return time.Unix(int64(v.Int32())*24*60*60, 0).UTC(), nil
+		}
+	}
+
+	format := opts.TimeFormat
+	if format == "" {
+		format = Unix
+	}
+	switch v.Kind() {
+	case parquet.Int32:
+		return unixTime(int64(v.Int32()), format), nil
+	case parquet.Int64:
+		return unixTime(v.Int64(), format), nil
+	case parquet.ByteArray:
+		return parseTime(string(v.ByteArray()), opts)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type %s", v.Kind())
+	}
+}
@@ -0,0 +1,116 @@
+package data_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pedropmedina/maximus/data"
+)
+
+type parquetBar struct {
+	Timestamp int64    `parquet:"timestamp"`
+	Open      *float64 `parquet:"open,optional"`
+	High      *float64 `parquet:"high,optional"`
+	Low       *float64 `parquet:"low,optional"`
+	Close     *float64 `parquet:"close,optional"`
+	Volume    *float64 `parquet:"volume,optional"`
+}
+
+// parquetFile writes rows to an in-memory Parquet file.
+func parquetFile[T any](t *testing.T, rows ...T) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[T](&buf)
+	if _, err := w.Write(rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func price(v float64) *float64 {
+	return &v
+}
+
+func TestReadParquet(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bar := func(i int) parquetBar {
+		return parquetBar{
+			Timestamp: day.AddDate(0, 0, i).Unix(),
+			Open:      price(1),
+			High:      price(2),
+			Low:       price(0.5),
+			Close:     price(1.5),
+		}
+	}
+	nullClose := bar(1)
+	nullClose.Close = nil
+	nullOpen := bar(0)
+	nullOpen.Open = nil
+
+	tests := []struct {
+		name string
+		rows []parquetBar
+		err  error
+		msg  string
+	}{
+		{name: "optional columns", rows: []parquetBar{bar(0), bar(1)}},
+		{
+			name: "null close",
+			rows: []parquetBar{bar(0), nullClose},
+			err:  data.ErrMissingValue,
+			msg:  `row 2: missing value in column "close"`,
+		},
+		{
+			name: "null open",
+			rows: []parquetBar{nullOpen},
+			err:  data.ErrMissingValue,
+			msg:  `row 1: missing value in column "open"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := parquetFile(t, tt.rows...)
+			bars, err := data.ReadParquet(r, r.Size(), data.Opts{Symbol: "X"})
+			if tt.err == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(bars["X"]) != len(tt.rows) {
+					t.Fatalf("got %d bars, want %d", len(bars["X"]), len(tt.rows))
+				}
+				b := bars["X"][1]
+				if !b.Timestamp.Equal(day.AddDate(0, 0, 1)) || b.Open != 1 || b.High != 2 || b.Low != 0.5 || b.Close != 1.5 || b.Volume != 0 {
+					t.Errorf("got %+v, want the second day with no volume", b)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("got %q, want it to contain %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestReadParquetMissingColumn(t *testing.T) {
+	type noClose struct {
+		Timestamp int64   `parquet:"timestamp"`
+		Open      float64 `parquet:"open"`
+		High      float64 `parquet:"high"`
+		Low       float64 `parquet:"low"`
+	}
+	r := parquetFile(t, noClose{Timestamp: 1704153600, Open: 1, High: 2, Low: 0.5})
+	_, err := data.ReadParquet(r, r.Size(), data.Opts{Symbol: "X"})
+	if !errors.Is(err, data.ErrMissingColumn) {
+		t.Errorf("got %v, want %v", err, data.ErrMissingColumn)
+	}
+}
@@ -0,0 +1,196 @@
+package indicators
+
+import (
+	"math"
+	"slices"
+)
+
+// Oscillators return a value per input value and, like moving averages, are
+// computed over the values available so far during the warm-up.
+
+// MACDResult holds the MACD lines aligned with the input values.
+type MACDResult struct {
+	// MACD is the fast EMA minus the slow EMA.
+	MACD []float64
+	// Signal is the EMA of the MACD line.
+	Signal []float64
+	// Histogram is the MACD line minus the signal line.
+	Histogram []float64
+}
+
+// StochasticResult holds the stochastic oscillator's lines aligned with the input
+// values. Both are in [0, 100].
+type StochasticResult struct {
+	K []float64
+	D []float64
+}
+
+// RSI (Relative Strength Index) measures the speed of price changes in [0, 100]:
+// 100 - 100 / (1 + average gain / average loss). Averages use Wilder's smoothing,
+// an EMA with a smoothing factor of 1 / period seeded with the SMA of the first
+// period changes. It's 50 until there's a change and 100 when there are no losses.
+func RSI(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	if len(values) == 0 {
+		return results
+	}
+	gains := make([]float64, len(values)-1)
+	losses := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		gains[i-1] = max(change, 0)
+		losses[i-1] = max(-change, 0)
+	}
+	avgGains := ema(1/float64(period), period, gains)
+	avgLosses := ema(1/float64(period), period, losses)
+
+	results[0] = 50
+	for i := range avgGains {
+		gain, loss := avgGains[i], avgLosses[i]
+		switch {
+		case loss == 0 && gain == 0:
+			results[i+1] = 50
+		case loss == 0:
+			results[i+1] = 100
+		default:
+			results[i+1] = 100 - 100/(1+gain/loss)
+		}
+	}
+	return results
+}
+
+// MACD (Moving Average Convergence Divergence) is the difference between the fast
+// and slow EMAs along with its signal EMA e.g. MACD(12, 26, 9, closes). The signal
+// EMA starts once both EMAs are past their warm-up, like TA-Lib's, and is the MACD
+// line itself until then.
+func MACD(fast, slow, signal int, values []float64) MACDResult {
+	fastEMA := EMA(fast, values)
+	slowEMA := EMA(slow, values)
+	macd := make([]float64, len(values))
+	for i := range macd {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	signalEMA := emaFrom(signal, max(fast, slow, 1)-1, macd)
+	histogram := make([]float64, len(values))
+	for i := range histogram {
+		histogram[i] = macd[i] - signalEMA[i]
+	}
+	return MACDResult{MACD: macd, Signal: signalEMA, Histogram: histogram}
+}
+
+// Stochastic is the stochastic oscillator. The raw %K is where the close sits
+// within the high-low range over period: 100 × (close - lowest) / (highest - lowest),
+// 50 when the range is flat. %K is its SMA over smoothK, 1 for the fast stochastic,
+// and %D is the SMA of %K over smoothD e.g. Stochastic(14, 3, 3, highs, lows, closes).
+func Stochastic(period, smoothK, smoothD int, high, low, close []float64) StochasticResult {
+	truncate(&high, &low, &close)
+	highest := Highest(period, high)
+	lowest := Lowest(period, low)
+	raw := make([]float64, len(close))
+	for i, c := range close {
+		raw[i] = position(c, lowest[i], highest[i])
+	}
+	k := SMA(max(smoothK, 1), raw)
+	return StochasticResult{K: k, D: SMA(max(smoothD, 1), k)}
+}
+
+// StochRSI is the stochastic oscillator applied to the RSI over rsiPeriod which
+// makes it more sensitive e.g. StochRSI(14, 14, 3, 3, closes).
+func StochRSI(rsiPeriod, period, smoothK, smoothD int, values []float64) StochasticResult {
+	rsi := RSI(rsiPeriod, values)
+	return Stochastic(period, smoothK, smoothD, rsi, rsi, rsi)
+}
+
+// CCI (Commodity Channel Index) measures how far the typical price, (high + low +
+// close) / 3, is from its SMA over period relative to its mean deviation scaled by
+// 0.015. It's 0 when there's no deviation.
+func CCI(period int, high, low, close []float64) []float64 {
+	truncate(&high, &low, &close)
+	period = max(period, 1)
+	typical := make([]float64, len(close))
+	for i := range typical {
+		typical[i] = (high[i] + low[i] + close[i]) / 3
+	}
+	sma := SMA(period, typical)
+	results := make([]float64, len(close))
+	for i, tp := range typical {
+		window := typical[max(i-period+1, 0) : i+1]
+		deviation := float64(0)
+		for _, v := range window {
+			deviation += math.Abs(v - sma[i])
+		}
+		deviation /= float64(len(window))
+		if deviation > 0 {
+			results[i] = (tp - sma[i]) / (0.015 * deviation)
+		}
+	}
+	return results
+}
+
+// WilliamsR (Williams %R) is where the close sits within the high-low range over
+// period in [-100, 0]: -100 × (highest - close) / (highest - lowest). It's -50 when
+// the range is flat.
+func WilliamsR(period int, high, low, close []float64) []float64 {
+	truncate(&high, &low, &close)
+	highest := Highest(period, high)
+	lowest := Lowest(period, low)
+	results := make([]float64, len(close))
+	for i, c := range close {
+		results[i] = position(c, lowest[i], highest[i]) - 100
+	}
+	return results
+}
+
+// ROC (Rate of Change) is the percentage change over period:
+// 100 × (value - value n periods ago) / value n periods ago. It's 0 when the
+// previous value is 0.
+func ROC(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	for i, v := range values {
+		if prev := values[max(i-period, 0)]; prev != 0 {
+			results[i] = 100 * (v - prev) / prev
+		}
+	}
+	return results
+}
+
+// Momentum is the change over period: value - value n periods ago.
+func Momentum(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	for i, v := range values {
+		results[i] = v - values[max(i-period, 0)]
+	}
+	return results
+}
+
+// Highest returns the highest value over period.
+func Highest(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	for i := range values {
+		results[i] = slices.Max(values[max(i-period+1, 0) : i+1])
+	}
+	return results
+}
+
+// Lowest returns the lowest value over period.
+func Lowest(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	for i := range values {
+		results[i] = slices.Min(values[max(i-period+1, 0) : i+1])
+	}
+	return results
+}
+
+// position returns where v sits within [low, high] in [0, 100] or 50 when the
+// range is flat.
+func position(v, low, high float64) float64 {
+	if high == low {
+		return 50
+	}
+	return 100 * (v - low) / (high - low)
+}
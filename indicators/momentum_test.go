@@ -0,0 +1,250 @@
+package indicators_test
+
+import (
+	"testing"
+
+	"github.com/pedropmedina/maximus/indicators"
+)
+
+// highs and lows complete `prices` into bars for indicators taking OHLC.
+var (
+	highs = []float64{
+		44.55, 44.40, 44.32, 44.20, 44.50, 45.00, 45.25, 45.60, 45.95, 46.30,
+		46.10, 46.20, 46.05, 46.40, 46.50, 46.35, 46.25, 46.60, 46.55, 46.10,
+	}
+	lows = []float64{
+		44.10, 43.90, 43.85, 43.40, 43.75, 44.40, 44.80, 45.05, 45.40, 45.80,
+		45.60, 45.70, 45.45, 45.70, 46.00, 45.85, 45.80, 46.05, 46.10, 45.50,
+	}
+)
+
+// TestOscillators checks every oscillator against reference values. Values from
+// TA-Lib's lookback on are its outputs, the ones before it are the warm-up computed
+// over the values available so far.
+func TestOscillators(t *testing.T) {
+	macd := indicators.MACD(5, 10, 3, prices)
+	stoch := indicators.Stochastic(5, 3, 3, highs, lows, prices)
+	fast := indicators.Stochastic(5, 1, 3, highs, lows, prices)
+	stochRSI := indicators.StochRSI(5, 5, 1, 3, prices)
+
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+	}{
+		{
+			name: "RSI",
+			got:  indicators.RSI(14, prices),
+			// TA-Lib from index 14
+			want: []float64{
+				50, 0, 19.35483871, 7.05882353, 49.68152866,
+				61.83574879, 66.23931624, 70.30075188, 74.35064935, 76.20481928,
+				72.07977208, 73.15068493, 65.6019656, 70.46413502, 70.46413502,
+				66.24961855, 66.48094183, 69.34685316, 66.29471266, 57.91502067,
+			},
+		},
+		{
+			name: "RSI short period",
+			got:  indicators.RSI(5, prices),
+			// TA-Lib from index 5
+			want: []float64{
+				50, 0, 19.35483871, 7.05882353, 49.68152866,
+				61.83574879, 67.18587747, 72.8288908, 78.80794702, 81.68646769,
+				72.00755134, 74.7618932, 54.61120177, 70.47803955, 70.47803955,
+				57.38001967, 58.41506901, 69.96439319, 59.61619256, 38.10849339,
+			},
+		},
+		{
+			name: "MACD",
+			got:  macd.MACD,
+			// TA-Lib's EMA(5) - EMA(10) from index 9
+			want: []float64{
+				0, 0, 0, 0, 0,
+				0.121, 0.24733333, 0.38780556, 0.55992593, 0.71058025,
+				0.6420535, 0.58697506, 0.45772166, 0.46099426, 0.43484026,
+				0.35179625, 0.28972398, 0.29588311, 0.25252245, 0.125688,
+			},
+		},
+		{
+			name: "MACD signal",
+			got:  macd.Signal,
+			// EMA(3) of the MACD line seeded at index 9, from index 11
+			want: []float64{
+				0, 0, 0, 0, 0,
+				0.121, 0.24733333, 0.38780556, 0.55992593, 0.71058025,
+				0.67631687, 0.64653627, 0.55212897, 0.50656161, 0.47070094,
+				0.41124859, 0.35048629, 0.3231847, 0.28785358, 0.20677079,
+			},
+		},
+		{
+			name: "MACD histogram",
+			got:  macd.Histogram,
+			// MACD - signal from index 11
+			want: []float64{
+				0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0,
+				-0.03426337, -0.05956121, -0.0944073, -0.04556735, -0.03586067,
+				-0.05945234, -0.0607623, -0.02730159, -0.03533113, -0.08108279,
+			},
+		},
+		{
+			name: "Stochastic %K",
+			got:  stoch.K,
+			// TA-Lib STOCH from index 8
+			want: []float64{
+				53.33333333, 41.28205128, 41.80708181, 30.11626055, 47.32919255,
+				62.83514493, 87.37881904, 91.0283579, 92.9033579, 91.74641148,
+				85.3625731, 79.82923977, 58.13333333, 63.03391813, 63.24979114,
+				72.93233083, 62.22222222, 62.16931217, 62.20899471, 48.03872054,
+			},
+		},
+		{
+			name: "Stochastic %D",
+			got:  stoch.D,
+			// TA-Lib STOCH from index 8
+			want: []float64{
+				53.33333333, 47.30769231, 45.47415547, 37.73513121, 39.75084497,
+				46.76019934, 65.84771884, 80.41410729, 90.43684495, 91.8927091,
+				90.00411416, 85.64607478, 74.4417154, 66.99883041, 61.47234754,
+				66.4053467, 66.1347814, 65.77462174, 62.20017637, 57.47234247,
+			},
+		},
+		{
+			name: "fast Stochastic %K",
+			got:  fast.K,
+			// TA-Lib STOCHF from index 6
+			want: []float64{
+				53.33333333, 29.23076923, 42.85714286, 18.26086957, 80.86956522,
+				89.375, 91.89189189, 91.81818182, 95, 88.42105263,
+				72.66666667, 78.4, 23.33333333, 87.36842105, 79.04761905,
+				52.38095238, 55.23809524, 78.88888889, 52.5, 12.72727273,
+			},
+		},
+		{
+			name: "fast Stochastic %D",
+			got:  fast.D,
+			// TA-Lib STOCHF from index 6
+			want: []float64{
+				53.33333333, 41.28205128, 41.80708181, 30.11626055, 47.32919255,
+				62.83514493, 87.37881904, 91.0283579, 92.9033579, 91.74641148,
+				85.3625731, 79.82923977, 58.13333333, 63.03391813, 63.24979114,
+				72.93233083, 62.22222222, 62.16931217, 62.20899471, 48.03872054,
+			},
+		},
+		{
+			name: "StochRSI %K",
+			got:  stochRSI.K,
+			// TA-Lib STOCHRSI from index 11
+			want: []float64{
+				50, 0, 38.70967742, 14.11764706, 99.36305732,
+				100, 100, 100, 100, 100,
+				33.25156977, 28.45713047, 0, 58.60270338, 78.74090989,
+				13.74056029, 23.97369466, 96.07844271, 17.07260267, 0,
+			},
+		},
+		{
+			name: "StochRSI %D",
+			got:  stochRSI.D,
+			// TA-Lib STOCHRSI from index 11
+			want: []float64{
+				50, 25, 29.56989247, 17.60910816, 50.73012727,
+				71.16023479, 99.78768577, 100, 100, 100,
+				77.75052326, 53.90290008, 20.56956675, 29.01994462, 45.78120442,
+				50.36139119, 38.81838828, 44.59756589, 45.70824668, 37.71701512,
+			},
+		},
+		{
+			name: "CCI",
+			got:  indicators.CCI(5, highs, lows, prices),
+			// TA-Lib from index 4
+			want: []float64{
+				0, -66.66666667, -58.26771654, -133.33333333, 43.19852941,
+				163.36825766, 107.4120603, 94.81140126, 109.1112467, 110.45364892,
+				51.25067972, 58.83639545, -89.74358974, 92.55464481, 110.12861736,
+				17.85714286, -4.85436893, 111.11111111, 49.49053857, -129.62962963,
+			},
+		},
+		{
+			name: "Williams %R",
+			got:  indicators.WilliamsR(5, highs, lows, prices),
+			// TA-Lib from index 4
+			want: []float64{
+				-46.66666667, -70.76923077, -57.14285714, -81.73913043, -19.13043478,
+				-10.625, -8.10810811, -8.18181818, -5, -11.57894737,
+				-27.33333333, -21.6, -76.66666667, -12.63157895, -20.95238095,
+				-47.61904762, -44.76190476, -21.11111111, -47.5, -87.27272727,
+			},
+		},
+		{
+			name: "ROC",
+			got:  indicators.ROC(5, prices),
+			// TA-Lib from index 5
+			want: []float64{
+				0, -0.56382499, -0.42850699, -1.64636897, -0.022553,
+				1.10509698, 2.29076888, 2.87655719, 5.11350608, 3.94766524,
+				2.36448807, 2.06208426, 0.41831792, 0.95986038, 0.43402778,
+				0.23970364, 0, 1.75400132, -0.12964564, -1.38288678,
+			},
+		},
+		{
+			name: "Momentum",
+			got:  indicators.Momentum(5, prices),
+			// TA-Lib from index 5
+			want: []float64{
+				0, -0.25, -0.19, -0.73, -0.01,
+				0.49, 1.01, 1.27, 2.23, 1.75,
+				1.06, 0.93, 0.19, 0.44, 0.2,
+				0.11, 0, 0.8, -0.06, -0.64,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValues(t, tt.got, tt.want)
+		})
+	}
+}
+
+func TestOscillatorsEdgeCases(t *testing.T) {
+	t.Run("periods below 1", func(t *testing.T) {
+		for _, period := range []int{0, -3} {
+			assertValues(t, indicators.RSI(period, prices), indicators.RSI(1, prices))
+			assertValues(t, indicators.CCI(period, highs, lows, prices), indicators.CCI(1, highs, lows, prices))
+			assertValues(t, indicators.WilliamsR(period, highs, lows, prices), indicators.WilliamsR(1, highs, lows, prices))
+			assertValues(t, indicators.ROC(period, prices), indicators.ROC(1, prices))
+			assertValues(t, indicators.Momentum(period, prices), indicators.Momentum(1, prices))
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		for name, got := range map[string][]float64{
+			"RSI":        indicators.RSI(14, nil),
+			"MACD":       indicators.MACD(12, 26, 9, nil).MACD,
+			"Stochastic": indicators.Stochastic(14, 3, 3, nil, nil, nil).K,
+			"CCI":        indicators.CCI(20, nil, nil, nil),
+			"ROC":        indicators.ROC(10, nil),
+		} {
+			if len(got) != 0 {
+				t.Errorf("%s: got %v, want none", name, got)
+			}
+		}
+	})
+
+	t.Run("flat", func(t *testing.T) {
+		flat := []float64{10, 10, 10}
+		assertValues(t, indicators.RSI(2, flat), []float64{50, 50, 50})
+		assertValues(t, indicators.Stochastic(2, 1, 1, flat, flat, flat).K, []float64{50, 50, 50})
+		assertValues(t, indicators.WilliamsR(2, flat, flat, flat), []float64{-50, -50, -50})
+		assertValues(t, indicators.CCI(2, flat, flat, flat), []float64{0, 0, 0})
+	})
+
+	// Series are cut to the shortest one
+	t.Run("mismatched lengths", func(t *testing.T) {
+		want := indicators.CCI(5, highs[:10], lows[:10], prices[:10])
+		assertValues(t, indicators.CCI(5, highs, lows[:10], prices), want)
+		want = indicators.WilliamsR(5, highs[:10], lows[:10], prices[:10])
+		assertValues(t, indicators.WilliamsR(5, highs[:10], lows, prices), want)
+		got := indicators.Stochastic(5, 3, 3, highs, lows, prices[:10])
+		assertValues(t, got.K, indicators.Stochastic(5, 3, 3, highs[:10], lows[:10], prices[:10]).K)
+	})
+}
@@ -1,8 +1,16 @@
 package indicators
 
+import (
+	"math"
+	"slices"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
 // SMA (Simple Moving Average) takes the closing prices of an asset over time period
 // sums them up and divides them by the period/total e.g. (1 + 2 + 3 + 4) / 4 = 2.5
 func SMA(period int, values []float64) []float64 {
+	period = max(period, 1)
 	// Keep track of the sum up to i
 	sum := float64(0)
 	// Storage averages
@@ -29,3 +37,219 @@ func SMA(period int, values []float64) []float64 {
 
 	return results
 }
+
+// All moving averages return a value per input value. During the warm-up, when
+// there are fewer than period values, they're computed over the values available
+// so far the same way `SMA` is.
+
+// EMA (Exponential Moving Average) weighs recent values exponentially more with a
+// smoothing factor of 2 / (period + 1). It's seeded with the SMA of the first period
+// values so from then on it matches the usual definition e.g. TA-Lib's.
+func EMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	return ema(2/float64(period+1), period, values)
+}
+
+// ema is the exponential moving average with the given smoothing factor seeded
+// with the SMA of the first period values.
+func ema(alpha float64, period int, values []float64) []float64 {
+	results := make([]float64, len(values))
+	sum := float64(0)
+	for i, v := range values {
+		if i < period {
+			sum += v
+			results[i] = sum / float64(i+1)
+			continue
+		}
+		results[i] = results[i-1] + alpha*(v-results[i-1])
+	}
+	return results
+}
+
+// WMA (Weighted Moving Average) weighs values linearly from 1 for the oldest one
+// to period for the latest one.
+func WMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	// sum and weighted are the plain and weighted sums of the window so that
+	// sliding it is O(1): every weight drops by one and the oldest value leaves.
+	var sum, weighted float64
+	for i, v := range values {
+		n := min(i+1, period)
+		if i >= period {
+			weighted -= sum
+			sum -= values[i-period]
+		}
+		sum += v
+		weighted += float64(n) * v
+		results[i] = weighted / float64(n*(n+1)/2)
+	}
+	return results
+}
+
+// DEMA (Double Exponential Moving Average) reduces the EMA's lag:
+// 2 × EMA - EMA(EMA).
+func DEMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	e1 := EMA(period, values)
+	e2 := emaFrom(period, period-1, e1)
+	results := make([]float64, len(values))
+	for i := range results {
+		results[i] = 2*e1[i] - e2[i]
+	}
+	return results
+}
+
+// TEMA (Triple Exponential Moving Average) reduces the EMA's lag further:
+// 3 × EMA - 3 × EMA(EMA) + EMA(EMA(EMA)).
+func TEMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	e1 := EMA(period, values)
+	e2 := emaFrom(period, period-1, e1)
+	e3 := emaFrom(period, 2*(period-1), e2)
+	results := make([]float64, len(values))
+	for i := range results {
+		results[i] = 3*e1[i] - 3*e2[i] + e3[i]
+	}
+	return results
+}
+
+// emaFrom returns the EMA of values starting at index from. Values before it are
+// returned as is. Nested EMAs start once the inner one is warmed up so that they
+// match the usual definition e.g. TA-Lib's.
+func emaFrom(period, from int, values []float64) []float64 {
+	from = min(from, len(values))
+	results := slices.Clone(values[:from])
+	return append(results, EMA(period, values[from:])...)
+}
+
+// HMA (Hull Moving Average) is a WMA of period √period over the difference of
+// the WMAs of period / 2 and period: WMA(√n, 2 × WMA(n/2) - WMA(n)).
+func HMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	half := WMA(max(period/2, 1), values)
+	full := WMA(period, values)
+	diff := make([]float64, len(values))
+	for i := range diff {
+		diff[i] = 2*half[i] - full[i]
+	}
+	return WMA(int(math.Round(math.Sqrt(float64(period)))), diff)
+}
+
+// KAMA (Kaufman's Adaptive Moving Average) moves fast when prices trend and slow
+// when they're choppy. The efficiency ratio over period, the net change divided by
+// the sum of absolute changes, scales the smoothing between the EMAs of 2 and 30.
+// It's the value itself until there are period changes.
+func KAMA(period int, values []float64) []float64 {
+	period = max(period, 1)
+	fast, slow := 2.0/(2+1), 2.0/(30+1)
+	results := make([]float64, len(values))
+	volatility := float64(0)
+	for i, v := range values {
+		if i > 0 {
+			volatility += math.Abs(v - values[i-1])
+		}
+		if i > period {
+			volatility -= math.Abs(values[i-period] - values[i-period-1])
+		}
+		if i < period {
+			results[i] = v
+			continue
+		}
+		change := math.Abs(v - values[i-period])
+
+		er := float64(0)
+		if volatility > 0 {
+			er = change / volatility
+		}
+		sc := math.Pow(er*(fast-slow)+slow, 2)
+		results[i] = results[i-1] + sc*(v-results[i-1])
+	}
+	return results
+}
+
+// VWMA (Volume Weighted Moving Average) weighs values by their volume. Windows
+// without volume fall back to the SMA.
+func VWMA(period int, values, volumes []float64) []float64 {
+	truncate(&values, &volumes)
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	var sum, weighted, volume float64
+	for i, v := range values {
+		sum += v
+		weighted += v * volumes[i]
+		volume += volumes[i]
+		if i >= period {
+			sum -= values[i-period]
+			weighted -= values[i-period] * volumes[i-period]
+			volume -= volumes[i-period]
+		}
+		if volume > 0 {
+			results[i] = weighted / volume
+		} else {
+			results[i] = sum / float64(min(i+1, period))
+		}
+	}
+	return results
+}
+
+// ALMA (Arnaud Legoux Moving Average) weighs the window with a gaussian curve
+// centered at 0.85 of the window towards the latest value with a sigma of 6, the
+// usual defaults, for a smooth yet responsive average.
+func ALMA(period int, values []float64) []float64 {
+	const offset, sigma = 0.85, 6.0
+	period = max(period, 1)
+	results := make([]float64, len(values))
+	var weights []float64
+	for i := range values {
+		n := min(i+1, period)
+		if len(weights) != n {
+			m := offset * float64(n-1)
+			s := float64(n) / sigma
+			weights = make([]float64, n)
+			for j := range weights {
+				weights[j] = math.Exp(-math.Pow(float64(j)-m, 2) / (2 * s * s))
+			}
+		}
+		var sum, norm float64
+		for j, w := range weights {
+			sum += w * values[i-n+1+j]
+			norm += w
+		}
+		results[i] = sum / norm
+	}
+	return results
+}
+
+// SMAStream is the streaming version of `SMA` to be registered with `Strategy.I`.
+// Its values are identical to the ones of `SMA` over the same prices.
+type SMAStream struct {
+	period int
+	price  backtest.Price
+	// window is a ring buffer with the last period prices.
+	window []float64
+	sum    float64
+	n      int
+}
+
+// NewSMAStream returns a streaming SMA over the given bar price.
+func NewSMAStream(period int, price backtest.Price) *SMAStream {
+	period = max(period, 1)
+	return &SMAStream{period: period, price: price, window: make([]float64, period)}
+}
+
+func (s *SMAStream) Update(bar backtest.Bar) float64 {
+	v := bar.Price(s.price)
+	count := s.n + 1
+	s.sum += v
+
+	i := s.n % s.period
+	if s.n >= s.period {
+		s.sum -= s.window[i]
+		count = s.period
+	}
+	s.window[i] = v
+	s.n++
+
+	return s.sum / float64(count)
+}
@@ -0,0 +1,220 @@
+package indicators_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/indicators"
+)
+
+// prices are the closes from the RSI example of Wilder's "New Concepts in Technical
+// Trading Systems" commonly used to validate indicators.
+var prices = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+var volumes = []float64{
+	1200, 900, 1500, 3000, 800, 1100, 1000, 2500, 1800, 700,
+	1300, 1600, 900, 2100, 1000, 1400, 1100, 800, 1900, 2600,
+}
+
+func vwma(period int, values []float64) []float64 {
+	return indicators.VWMA(period, values, volumes)
+}
+
+// TestMovingAverages checks every average against reference values. Values from
+// TA-Lib's lookback on are its outputs, the ones before it are the warm-up computed
+// over the values available so far. HMA, VWMA and ALMA aren't in TA-Lib so their
+// values come from their definitions.
+func TestMovingAverages(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(period int, values []float64) []float64
+		period int
+		want   []float64
+	}{
+		{
+			name:   "EMA",
+			fn:     indicators.EMA,
+			period: 5,
+			// TA-Lib from index 4
+			want: []float64{
+				44.34, 44.215, 44.19333333, 44.0475, 44.104,
+				44.346, 44.59733333, 44.87155556, 45.19437037, 45.48958025,
+				45.6230535, 45.75870233, 45.70913489, 45.89942326, 46.02628217,
+				46.01752145, 46.02168097, 46.15112064, 46.17408043, 45.99605362,
+			},
+		},
+		{
+			name:   "WMA",
+			fn:     indicators.WMA,
+			period: 5,
+			// TA-Lib from index 4
+			want: []float64{
+				44.34, 44.17333333, 44.16166667, 43.941, 44.07066667,
+				44.31266667, 44.612, 44.95066667, 45.34466667, 45.67,
+				45.81533333, 45.93666667, 45.856, 45.986, 46.08666667,
+				46.08066667, 46.07733333, 46.20066667, 46.20733333, 46.02466667,
+			},
+		},
+		{
+			name:   "DEMA",
+			fn:     indicators.DEMA,
+			period: 5,
+			// TA-Lib from index 8
+			want: []float64{
+				44.34, 44.215, 44.19333333, 44.0475, 44.104,
+				44.467, 44.84555556, 45.26338889, 45.76608889, 46.06753251,
+				46.09733717, 46.16532401, 45.94717104, 46.18497294, 46.30122124,
+				46.19497367, 46.14275546, 46.31813009, 46.30072659, 45.96179985,
+			},
+		},
+		{
+			name:   "TEMA",
+			fn:     indicators.TEMA,
+			period: 5,
+			// TA-Lib from index 12
+			want: []float64{
+				44.34, 44.215, 44.19333333, 44.0475, 44.104,
+				44.588, 45.09377778, 45.65522222, 46.33780741, 46.50099671,
+				46.31720091, 46.2286476, 45.81535427, 46.12877078, 46.25667938,
+				46.10028788, 46.04204645, 46.28161405, 46.2494737, 45.82036464,
+			},
+		},
+		{
+			name:   "KAMA",
+			fn:     indicators.KAMA,
+			period: 5,
+			// TA-Lib from index 5
+			want: []float64{
+				44.34, 44.09, 44.15, 43.61, 44.33,
+				44.35143572, 44.44604347, 44.59413186, 45.14785103, 45.56213946,
+				45.64667048, 45.73945964, 45.73671303, 45.76400277, 45.77550862,
+				45.77814561, 45.77919392, 45.88979213, 45.89347243, 45.86612423,
+			},
+		},
+		{
+			name:   "HMA",
+			fn:     indicators.HMA,
+			period: 9,
+			want: []float64{
+				44.34, 44.22888889, 44.19527778, 44.05327778, 44.02594444,
+				44.22680159, 44.6263254, 45.13688095, 45.65791799, 46.08809259,
+				46.29074074, 46.33951852, 46.15662963, 46.10355556, 46.16092593,
+				46.19459259, 46.17955556, 46.22233333, 46.2612963, 46.11762963,
+			},
+		},
+		{
+			name:   "VWMA",
+			fn:     vwma,
+			period: 5,
+			want: []float64{
+				44.34, 44.23285714, 44.19833333, 43.93090909, 43.97405405,
+				44.04287671, 44.18, 44.55440476, 45.26930556, 45.45507042,
+				45.62671233, 45.77506329, 45.89238095, 46.03, 46.06115942,
+				46.08071429, 46.08461538, 46.19203125, 46.17080645, 45.97987179,
+			},
+		},
+		{
+			name:   "ALMA",
+			fn:     indicators.ALMA,
+			period: 9,
+			want: []float64{
+				44.34, 44.10027282, 44.13192935, 43.8746072, 43.97148383,
+				44.34422333, 44.68322744, 44.9709885, 45.25842002, 45.59635282,
+				45.80629799, 45.92661922, 45.88842161, 45.93708471, 46.04244644,
+				46.10072472, 46.10118096, 46.15094351, 46.19740738, 46.10312559,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValues(t, tt.fn(tt.period, prices), tt.want)
+
+			if got := tt.fn(tt.period, nil); len(got) != 0 {
+				t.Errorf("empty values: got %v, want none", got)
+			}
+			// Periods below 1 behave as a period of 1
+			for _, period := range []int{0, -3} {
+				assertValues(t, tt.fn(period, prices), tt.fn(1, prices))
+			}
+		})
+	}
+}
+
+func TestSMA(t *testing.T) {
+	tests := []struct {
+		name   string
+		period int
+		values []float64
+		want   []float64
+	}{
+		{"warm-up", 3, []float64{1, 2, 3, 4, 5}, []float64{1, 1.5, 2, 3, 4}},
+		{"period longer than values", 10, []float64{2, 4}, []float64{2, 3}},
+		{"zero period", 0, []float64{2, 4}, []float64{2, 4}},
+		{"negative period", -1, []float64{2, 4}, []float64{2, 4}},
+		{"empty", 3, nil, []float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValues(t, indicators.SMA(tt.period, tt.values), tt.want)
+		})
+	}
+}
+
+func assertValues(t *testing.T, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-8 {
+			t.Errorf("value %d: got %.10f, want %.10f", i, got[i], want[i])
+		}
+	}
+}
+
+// TestSMAStream runs the streaming SMA through the backtest loop and compares it
+// with the batch SMA over the same bars on every bar.
+func TestSMAStream(t *testing.T) {
+	bars := make([]backtest.Bar, len(prices))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, p := range prices {
+		bars[i] = backtest.Bar{Timestamp: start.AddDate(0, 0, i), Open: p, High: p, Low: p, Close: p}
+	}
+
+	for _, period := range []int{1, 5, 14, 30} {
+		bt, err := backtest.New(map[string][]backtest.Bar{"X": bars})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var calls int
+		var stream []float64
+		bt.Strategy("sma", func(s *backtest.Strategy) {
+			// Registered late so the stream also has to catch up with earlier bars
+			if len(s.Data["X"].Bars()) < 3 {
+				return
+			}
+			calls++
+			stream = s.I("X", "sma", func() backtest.Indicator {
+				return indicators.NewSMAStream(period, backtest.Close)
+			})
+			assertValues(t, stream, indicators.SMA(period, s.Data["X"].Prices(backtest.Close)))
+		})
+		if _, err := bt.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if calls != len(bars)-2 {
+			t.Errorf("period %d: got %d calls, want %d", period, calls, len(bars)-2)
+		}
+		// Values are exactly the batch ones, not only close to them
+		if want := indicators.SMA(period, prices); !slices.Equal(stream, want) {
+			t.Errorf("period %d: got %v, want %v", period, stream, want)
+		}
+	}
+}
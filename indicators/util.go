@@ -0,0 +1,16 @@
+package indicators
+
+import "math"
+
+// truncate cuts every series to the length of the shortest one. Indicators taking
+// several series e.g. high, low and close expect them aligned and return a value
+// per index of the shortest one instead of panicking on mismatched lengths.
+func truncate(series ...*[]float64) {
+	n := math.MaxInt
+	for _, s := range series {
+		n = min(n, len(*s))
+	}
+	for _, s := range series {
+		*s = (*s)[:n]
+	}
+}
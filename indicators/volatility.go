@@ -0,0 +1,175 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// OHLCV holds bars' prices and volume as separate slices aligned with the bars so
+// that they can be passed to indicators taking high, low, close, ...
+//
+//	ohlcv := indicators.FromBars(s.Data["SPY"].Bars())
+//	atr := indicators.ATR(14, ohlcv.High, ohlcv.Low, ohlcv.Close)
+type OHLCV struct {
+	Open   []float64
+	High   []float64
+	Low    []float64
+	Close  []float64
+	Volume []float64
+}
+
+// FromBars splits bars into their prices and volume.
+func FromBars(bars []backtest.Bar) OHLCV {
+	o := OHLCV{
+		Open:   make([]float64, len(bars)),
+		High:   make([]float64, len(bars)),
+		Low:    make([]float64, len(bars)),
+		Close:  make([]float64, len(bars)),
+		Volume: make([]float64, len(bars)),
+	}
+	for i, b := range bars {
+		o.Open[i] = b.Open
+		o.High[i] = b.High
+		o.Low[i] = b.Low
+		o.Close[i] = b.Close
+		o.Volume[i] = b.Volume
+	}
+	return o
+}
+
+// BandsResult holds a channel's bands aligned with the input values.
+type BandsResult struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+// BollingerResult holds the Bollinger Bands along with where values sit within them.
+type BollingerResult struct {
+	BandsResult
+	// PercentB is where the value sits within the bands: (value - lower) / (upper -
+	// lower). 0.5 when the bands are flat.
+	PercentB []float64
+	// Bandwidth is the bands' width relative to the middle band: (upper - lower) /
+	// middle. 0 when the middle band is 0.
+	Bandwidth []float64
+}
+
+// TrueRange is the bar's range extended to the previous close when it gapped:
+// max(high - low, |high - previous close|, |low - previous close|). The first bar's
+// true range is its high - low.
+func TrueRange(high, low, close []float64) []float64 {
+	truncate(&high, &low, &close)
+	results := make([]float64, len(close))
+	for i := range close {
+		results[i] = high[i] - low[i]
+		if i > 0 {
+			results[i] = max(results[i], math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1]))
+		}
+	}
+	return results
+}
+
+// ATR (Average True Range) is the true range smoothed with Wilder's smoothing like
+// `RSI`'s averages. It's the go to measure of volatility for stops and sizing. Like
+// TA-Lib, averages start on the second bar as the first one has no previous close
+// and the first value is the first bar's true range.
+func ATR(period int, high, low, close []float64) []float64 {
+	period = max(period, 1)
+	tr := TrueRange(high, low, close)
+	if len(tr) == 0 {
+		return tr
+	}
+	return append(tr[:1], ema(1/float64(period), period, tr[1:])...)
+}
+
+// NATR (Normalized Average True Range) is the ATR as a percentage of the close so
+// that it can be compared across prices. It's 0 when the close is 0.
+func NATR(period int, high, low, close []float64) []float64 {
+	truncate(&high, &low, &close)
+	results := ATR(period, high, low, close)
+	for i, c := range close {
+		if c == 0 {
+			results[i] = 0
+			continue
+		}
+		results[i] = 100 * results[i] / c
+	}
+	return results
+}
+
+// StdDev returns the population standard deviation of values over period.
+func StdDev(period int, values []float64) []float64 {
+	period = max(period, 1)
+	sma := SMA(period, values)
+	results := make([]float64, len(values))
+	for i := range values {
+		window := values[max(i-period+1, 0) : i+1]
+		variance := float64(0)
+		for _, v := range window {
+			variance += (v - sma[i]) * (v - sma[i])
+		}
+		results[i] = math.Sqrt(variance / float64(len(window)))
+	}
+	return results
+}
+
+// Bollinger returns the Bollinger Bands: the SMA over period ± k standard deviations
+// e.g. Bollinger(20, 2, closes).
+func Bollinger(period int, k float64, values []float64) BollingerResult {
+	middle := SMA(max(period, 1), values)
+	stdDev := StdDev(period, values)
+	r := BollingerResult{
+		BandsResult: BandsResult{
+			Upper:  make([]float64, len(values)),
+			Middle: middle,
+			Lower:  make([]float64, len(values)),
+		},
+		PercentB:  make([]float64, len(values)),
+		Bandwidth: make([]float64, len(values)),
+	}
+	for i, v := range values {
+		r.Upper[i] = middle[i] + k*stdDev[i]
+		r.Lower[i] = middle[i] - k*stdDev[i]
+		r.PercentB[i] = position(v, r.Lower[i], r.Upper[i]) / 100
+		if middle[i] != 0 {
+			r.Bandwidth[i] = (r.Upper[i] - r.Lower[i]) / middle[i]
+		}
+	}
+	return r
+}
+
+// Keltner returns the Keltner Channels: the EMA of the close over period ±
+// multiplier × ATR over atrPeriod e.g. Keltner(20, 10, 2, highs, lows, closes).
+func Keltner(period, atrPeriod int, multiplier float64, high, low, close []float64) BandsResult {
+	truncate(&high, &low, &close)
+	middle := EMA(period, close)
+	atr := ATR(atrPeriod, high, low, close)
+	r := BandsResult{
+		Upper:  make([]float64, len(close)),
+		Middle: middle,
+		Lower:  make([]float64, len(close)),
+	}
+	for i := range close {
+		r.Upper[i] = middle[i] + multiplier*atr[i]
+		r.Lower[i] = middle[i] - multiplier*atr[i]
+	}
+	return r
+}
+
+// Donchian returns the Donchian Channels: the highest high and lowest low over
+// period with their midpoint as the middle band. Breakouts are usually checked
+// against the previous bar's bands as the current bar is part of them.
+func Donchian(period int, high, low []float64) BandsResult {
+	truncate(&high, &low)
+	r := BandsResult{
+		Upper:  Highest(period, high),
+		Middle: make([]float64, len(high)),
+		Lower:  Lowest(period, low),
+	}
+	for i := range r.Middle {
+		r.Middle[i] = (r.Upper[i] + r.Lower[i]) / 2
+	}
+	return r
+}
@@ -0,0 +1,230 @@
+package indicators_test
+
+import (
+	"testing"
+
+	"github.com/pedropmedina/maximus/indicators"
+)
+
+// TestVolatility checks every volatility indicator against reference values. Values
+// from TA-Lib's lookback on are its outputs, the ones before it are the warm-up
+// computed over the values available so far. Donchian isn't in TA-Lib so its values
+// come from its definition.
+func TestVolatility(t *testing.T) {
+	bollinger := indicators.Bollinger(5, 2, prices)
+	keltner := indicators.Keltner(5, 5, 2, highs, lows, prices)
+	donchian := indicators.Donchian(5, highs, lows)
+
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+	}{
+		{
+			name: "True range",
+			got:  indicators.TrueRange(highs, lows, prices),
+			// TA-Lib from index 1, the first bar has no previous close
+			want: []float64{
+				0.45, 0.5, 0.47, 0.8, 0.89,
+				0.67, 0.45, 0.55, 0.55, 0.5,
+				0.5, 0.5, 0.6, 0.79, 0.5,
+				0.5, 0.45, 0.57, 0.45, 0.72,
+			},
+		},
+		{
+			name: "ATR",
+			got:  indicators.ATR(5, highs, lows, prices),
+			// TA-Lib from index 5
+			want: []float64{
+				0.45, 0.5, 0.485, 0.59, 0.665,
+				0.666, 0.6228, 0.60824, 0.596592, 0.5772736,
+				0.56181888, 0.5494551, 0.55956408, 0.60565127, 0.58452101,
+				0.56761681, 0.54409345, 0.54927476, 0.52941981, 0.56753585,
+			},
+		},
+		{
+			name: "NATR",
+			got:  indicators.NATR(5, highs, lows, prices),
+			// TA-Lib from index 5
+			want: []float64{
+				1.01488498, 1.134044, 1.09852775, 1.35290071, 1.50011279,
+				1.48561231, 1.38093126, 1.33914575, 1.30146597, 1.25276389,
+				1.224273, 1.19368912, 1.22684517, 1.30866739, 1.26300997,
+				1.23394959, 1.18204095, 1.18352674, 1.14543446, 1.24350536,
+			},
+		},
+		{
+			name: "StdDev",
+			got:  indicators.StdDev(5, prices),
+			// TA-Lib from index 4
+			want: []float64{
+				0, 0.125, 0.10656245, 0.26892146, 0.26575176,
+				0.39407613, 0.52274659, 0.63426808, 0.51297563, 0.45972165,
+				0.35573023, 0.23318662, 0.16528763, 0.22247697, 0.25309287,
+				0.24568272, 0.24568272, 0.15861904, 0.15432433, 0.25651511,
+			},
+		},
+		{
+			name: "Bollinger upper",
+			got:  bollinger.Upper,
+			// TA-Lib from index 4
+			want: []float64{
+				44.34, 44.465, 44.40645823, 44.58534291, 44.63550353,
+				44.99015227, 45.44949319, 45.92653616, 46.12995127, 46.37344331,
+				46.37746047, 46.31837324, 46.22057526, 46.42295393, 46.52418574,
+				46.53136544, 46.53136544, 46.51723808, 46.49664867, 46.57303021,
+			},
+		},
+		{
+			name: "Bollinger middle",
+			got:  bollinger.Middle,
+			// TA-Lib from index 4
+			want: []float64{
+				44.34, 44.215, 44.19333333, 44.0475, 44.104,
+				44.202, 44.404, 44.658, 45.104, 45.454,
+				45.666, 45.852, 45.89, 45.978, 46.018,
+				46.04, 46.04, 46.2, 46.188, 46.06,
+			},
+		},
+		{
+			name: "Bollinger lower",
+			got:  bollinger.Lower,
+			// TA-Lib from index 4
+			want: []float64{
+				44.34, 43.965, 43.98020844, 43.50965709, 43.57249647,
+				43.41384773, 43.35850681, 43.38946384, 44.07804873, 44.53455669,
+				44.95453953, 45.38562676, 45.55942474, 45.53304607, 45.51181426,
+				45.54863456, 45.54863456, 45.88276192, 45.87935133, 45.54696979,
+			},
+		},
+		{
+			name: "Bollinger %B",
+			got:  bollinger.PercentB,
+			// From TA-Lib's bands from index 4
+			want: []float64{
+				0.5, 0.25, 0.39833817, 0.09328273, 0.71260442,
+				0.89840017, 0.83285726, 0.80034619, 0.8586915, 0.84042338,
+				0.65742266, 0.69083428, 0.07649583, 0.83936098, 0.75879828,
+				0.4592971, 0.48982427, 0.8309817, 0.55183888, 0.09066738,
+			},
+		},
+		{
+			name: "Bollinger bandwidth",
+			got:  bollinger.Bandwidth,
+			// From TA-Lib's bands from index 4
+			want: []float64{
+				0, 0.01130838, 0.00964512, 0.02442104, 0.02410228,
+				0.03566138, 0.04709005, 0.05681115, 0.0454927, 0.04045599,
+				0.03115931, 0.02034255, 0.01440729, 0.01935508, 0.02199947,
+				0.02134515, 0.02134515, 0.01373325, 0.01336489, 0.02227661,
+			},
+		},
+		{
+			name: "Keltner upper",
+			got:  keltner.Upper,
+			// TA-Lib's EMA(5) + 2 × ATR(5) from index 5
+			want: []float64{
+				45.24, 45.215, 45.16333333, 45.2275, 45.434,
+				45.678, 45.84293333, 46.08803556, 46.38755437, 46.64412745,
+				46.74669126, 46.85761254, 46.82826305, 47.11072579, 47.1953242,
+				47.15275507, 47.10986786, 47.24967016, 47.23292004, 47.13112531,
+			},
+		},
+		{
+			name: "Keltner middle",
+			got:  keltner.Middle,
+			// TA-Lib's EMA(5) from index 4
+			want: []float64{
+				44.34, 44.215, 44.19333333, 44.0475, 44.104,
+				44.346, 44.59733333, 44.87155556, 45.19437037, 45.48958025,
+				45.6230535, 45.75870233, 45.70913489, 45.89942326, 46.02628217,
+				46.01752145, 46.02168097, 46.15112064, 46.17408043, 45.99605362,
+			},
+		},
+		{
+			name: "Keltner lower",
+			got:  keltner.Lower,
+			// TA-Lib's EMA(5) - 2 × ATR(5) from index 5
+			want: []float64{
+				43.44, 43.215, 43.22333333, 42.8675, 42.774,
+				43.014, 43.35173333, 43.65507556, 44.00118637, 44.33503305,
+				44.49941574, 44.65979212, 44.59000672, 44.68812073, 44.85724015,
+				44.88228783, 44.93349407, 45.05257113, 45.11524082, 44.86098193,
+			},
+		},
+		{
+			name: "Donchian upper",
+			got:  donchian.Upper,
+			want: []float64{
+				44.55, 44.55, 44.55, 44.55, 44.55,
+				45, 45.25, 45.6, 45.95, 46.3,
+				46.3, 46.3, 46.3, 46.4, 46.5,
+				46.5, 46.5, 46.6, 46.6, 46.6,
+			},
+		},
+		{
+			name: "Donchian middle",
+			got:  donchian.Middle,
+			want: []float64{
+				44.325, 44.225, 44.2, 43.975, 43.975,
+				44.2, 44.325, 44.5, 44.85, 45.35,
+				45.55, 45.675, 45.85, 45.925, 45.975,
+				45.975, 45.975, 46.15, 46.2, 46.05,
+			},
+		},
+		{
+			name: "Donchian lower",
+			got:  donchian.Lower,
+			want: []float64{
+				44.1, 43.9, 43.85, 43.4, 43.4,
+				43.4, 43.4, 43.4, 43.75, 44.4,
+				44.8, 45.05, 45.4, 45.45, 45.45,
+				45.45, 45.45, 45.7, 45.8, 45.5,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValues(t, tt.got, tt.want)
+		})
+	}
+}
+
+func TestVolatilityEdgeCases(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		for name, got := range map[string][]float64{
+			"TrueRange": indicators.TrueRange(nil, nil, nil),
+			"ATR":       indicators.ATR(14, nil, nil, nil),
+			"StdDev":    indicators.StdDev(20, nil),
+			"Bollinger": indicators.Bollinger(20, 2, nil).PercentB,
+			"Keltner":   indicators.Keltner(20, 10, 2, nil, nil, nil).Upper,
+			"Donchian":  indicators.Donchian(20, nil, nil).Middle,
+		} {
+			if len(got) != 0 {
+				t.Errorf("%s: got %v, want none", name, got)
+			}
+		}
+	})
+
+	t.Run("flat", func(t *testing.T) {
+		flat := []float64{10, 10, 10}
+		bollinger := indicators.Bollinger(2, 2, flat)
+		assertValues(t, bollinger.Upper, flat)
+		assertValues(t, bollinger.Lower, flat)
+		// Closes within a band of no width are in its middle
+		assertValues(t, bollinger.PercentB, []float64{0.5, 0.5, 0.5})
+		assertValues(t, bollinger.Bandwidth, []float64{0, 0, 0})
+		assertValues(t, indicators.ATR(2, flat, flat, flat), []float64{0, 0, 0})
+		assertValues(t, indicators.NATR(2, flat, flat, flat), []float64{0, 0, 0})
+	})
+
+	// Series are cut to the shortest one
+	t.Run("mismatched lengths", func(t *testing.T) {
+		want := indicators.ATR(5, highs[:10], lows[:10], prices[:10])
+		assertValues(t, indicators.ATR(5, highs, lows[:10], prices), want)
+		keltner := indicators.Keltner(5, 5, 2, highs[:10], lows, prices)
+		assertValues(t, keltner.Upper, indicators.Keltner(5, 5, 2, highs[:10], lows[:10], prices[:10]).Upper)
+		donchian := indicators.Donchian(5, highs, lows[:10])
+		assertValues(t, donchian.Upper, indicators.Highest(5, highs[:10]))
+	})
+}
@@ -0,0 +1,237 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// VWAPResult holds the session anchored VWAP and its standard deviation bands
+// aligned with the bars.
+type VWAPResult struct {
+	VWAP  []float64
+	Upper []float64
+	Lower []float64
+}
+
+// VolumeLevel is a price bin of a volume profile.
+type VolumeLevel struct {
+	// Low and High bound the bin's prices.
+	Low    float64
+	High   float64
+	Volume float64
+}
+
+// VolumeProfileResult holds the volume traded at each price level.
+type VolumeProfileResult struct {
+	// Levels are sorted by price from the lowest.
+	Levels []VolumeLevel
+	// POC (point of control) is the index of the level with the most volume or -1
+	// when there's no volume.
+	POC int
+}
+
+// VWAP (Volume Weighted Average Price) is the average typical price, (high + low +
+// close) / 3, weighted by volume since the session's open e.g. `backtest.NYSE`. A
+// zero session is anchored to midnight UTC. Bands are k volume weighted standard
+// deviations away from it. Until there's volume in the session it's the typical
+// price. Bars outside the session e.g. extended hours are skipped and their values
+// are NaN.
+//
+//	vwap := indicators.VWAP(s.Data["SPY"].Bars(), backtest.NYSE, 2)
+func VWAP(bars []backtest.Bar, session backtest.Session, k float64) VWAPResult {
+	r := VWAPResult{
+		VWAP:  make([]float64, len(bars)),
+		Upper: make([]float64, len(bars)),
+		Lower: make([]float64, len(bars)),
+	}
+	var anchor time.Time
+	var volume, weighted, squared float64
+	for i, b := range bars {
+		if !session.Contains(b.Timestamp) {
+			r.VWAP[i], r.Upper[i], r.Lower[i] = math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		if open := session.OpenOn(b.Timestamp); !open.Equal(anchor) {
+			anchor = open
+			volume, weighted, squared = 0, 0, 0
+		}
+		tp := (b.High + b.Low + b.Close) / 3
+		volume += b.Volume
+		weighted += b.Volume * tp
+		squared += b.Volume * tp * tp
+
+		vwap, deviation := tp, float64(0)
+		if volume > 0 {
+			vwap = weighted / volume
+			deviation = math.Sqrt(max(squared/volume-vwap*vwap, 0))
+		}
+		r.VWAP[i] = vwap
+		r.Upper[i] = vwap + k*deviation
+		r.Lower[i] = vwap - k*deviation
+	}
+	return r
+}
+
+// OBV (On-Balance Volume) adds the volume on up closes and subtracts it on down
+// closes. It starts at 0.
+func OBV(close, volume []float64) []float64 {
+	truncate(&close, &volume)
+	results := make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		results[i] = results[i-1]
+		switch {
+		case close[i] > close[i-1]:
+			results[i] += volume[i]
+		case close[i] < close[i-1]:
+			results[i] -= volume[i]
+		}
+	}
+	return results
+}
+
+// MFI (Money Flow Index) is a volume weighted RSI in [0, 100] over period. Money
+// flow, typical price × volume, is positive when the typical price rises and
+// negative when it falls: 100 - 100 / (1 + positive flow / negative flow). It's
+// 50 when there's no flow and 100 when there's no negative flow.
+func MFI(period int, high, low, close, volume []float64) []float64 {
+	truncate(&high, &low, &close, &volume)
+	period = max(period, 1)
+	typical := make([]float64, len(close))
+	for i := range typical {
+		typical[i] = (high[i] + low[i] + close[i]) / 3
+	}
+	// flow returns the positive and negative money flow of bar i.
+	flow := func(i int) (float64, float64) {
+		if i == 0 {
+			return 0, 0
+		}
+		switch f := typical[i] * volume[i]; {
+		case typical[i] > typical[i-1]:
+			return f, 0
+		case typical[i] < typical[i-1]:
+			return 0, f
+		}
+		return 0, 0
+	}
+
+	results := make([]float64, len(close))
+	var positive, negative float64
+	for i := range close {
+		pos, neg := flow(i)
+		positive += pos
+		negative += neg
+		if i >= period {
+			pos, neg := flow(i - period)
+			positive -= pos
+			negative -= neg
+		}
+		switch {
+		case positive <= 0 && negative <= 0:
+			results[i] = 50
+		case negative <= 0:
+			results[i] = 100
+		default:
+			results[i] = 100 - 100/(1+positive/negative)
+		}
+	}
+	return results
+}
+
+// AD (Accumulation/Distribution) adds up the volume weighted by where the close
+// sits within the bar's range: ((close - low) - (high - close)) / (high - low).
+func AD(high, low, close, volume []float64) []float64 {
+	truncate(&high, &low, &close, &volume)
+	results := make([]float64, len(close))
+	sum := float64(0)
+	for i := range close {
+		sum += clv(high[i], low[i], close[i]) * volume[i]
+		results[i] = sum
+	}
+	return results
+}
+
+// CMF (Chaikin Money Flow) is the accumulation/distribution volume over period
+// divided by the volume over period in [-1, 1]. It's 0 when there's no volume.
+func CMF(period int, high, low, close, volume []float64) []float64 {
+	truncate(&high, &low, &close, &volume)
+	period = max(period, 1)
+	results := make([]float64, len(close))
+	var flow, vol float64
+	for i := range close {
+		flow += clv(high[i], low[i], close[i]) * volume[i]
+		vol += volume[i]
+		if j := i - period; j >= 0 {
+			flow -= clv(high[j], low[j], close[j]) * volume[j]
+			vol -= volume[j]
+		}
+		if vol > 0 {
+			results[i] = flow / vol
+		}
+	}
+	return results
+}
+
+// clv (Close Location Value) is where the close sits within the bar's range in
+// [-1, 1] or 0 when the range is flat.
+func clv(high, low, close float64) float64 {
+	if high == low {
+		return 0
+	}
+	return ((close - low) - (high - close)) / (high - low)
+}
+
+// VolumeProfile splits the price range of the given bars in bins levels of equal
+// height and adds up the volume traded at each of them. A bar's volume is spread
+// evenly across its range so each level gets the share of the range it overlaps.
+//
+//	bars := s.Data["SPY"].Bars()
+//	profile := indicators.VolumeProfile(24, indicators.FromBars(bars[len(bars)-78:]))
+//	poc := profile.Levels[profile.POC]
+func VolumeProfile(bins int, ohlcv OHLCV) VolumeProfileResult {
+	truncate(&ohlcv.High, &ohlcv.Low, &ohlcv.Volume)
+	r := VolumeProfileResult{POC: -1}
+	if len(ohlcv.Volume) == 0 || bins < 1 {
+		return r
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for i := range ohlcv.Volume {
+		low = min(low, ohlcv.Low[i])
+		high = max(high, ohlcv.High[i])
+	}
+	height := (high - low) / float64(bins)
+
+	r.Levels = make([]VolumeLevel, bins)
+	for i := range r.Levels {
+		r.Levels[i] = VolumeLevel{Low: low + float64(i)*height, High: low + float64(i+1)*height}
+	}
+	for i, volume := range ohlcv.Volume {
+		if volume <= 0 {
+			continue
+		}
+		l, h := ohlcv.Low[i], ohlcv.High[i]
+		// A flat bar or range puts all of the volume in a single level
+		if h == l || height == 0 {
+			bin := 0
+			if height > 0 {
+				bin = min(int((l-low)/height), bins-1)
+			}
+			r.Levels[bin].Volume += volume
+			continue
+		}
+		from := min(int((l-low)/height), bins-1)
+		to := min(int((h-low)/height), bins-1)
+		for bin := from; bin <= to; bin++ {
+			level := &r.Levels[bin]
+			overlap := min(h, level.High) - max(l, level.Low)
+			level.Volume += volume * max(overlap, 0) / (h - l)
+		}
+	}
+	for i, level := range r.Levels {
+		if level.Volume > 0 && (r.POC < 0 || level.Volume > r.Levels[r.POC].Volume) {
+			r.POC = i
+		}
+	}
+	return r
+}
@@ -0,0 +1,204 @@
+package indicators_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/indicators"
+)
+
+func TestVWAPSession(t *testing.T) {
+	ny := backtest.NYSE.Location
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, ny)
+	}
+	bar := func(ts time.Time, price, volume float64) backtest.Bar {
+		return backtest.Bar{Timestamp: ts, Open: price, High: price, Low: price, Close: price, Volume: volume}
+	}
+	bars := []backtest.Bar{
+		bar(at(4, 8, 0), 50, 1000), // pre-market
+		bar(at(4, 9, 30), 10, 100),
+		bar(at(4, 12, 0), 20, 300),
+		bar(at(4, 16, 30), 50, 1000), // after hours
+		bar(at(5, 9, 0), 50, 1000),   // pre-market
+		bar(at(5, 9, 30), 30, 100),
+		bar(at(5, 10, 0), 40, 100),
+	}
+	got := indicators.VWAP(bars, backtest.NYSE, 1)
+
+	nan := math.NaN()
+	want := []float64{nan, 10, 17.5, nan, nan, 30, 35}
+	for i := range want {
+		if math.IsNaN(want[i]) != math.IsNaN(got.VWAP[i]) || (!math.IsNaN(want[i]) && math.Abs(got.VWAP[i]-want[i]) > 1e-9) {
+			t.Errorf("vwap %d: got %f, want %f", i, got.VWAP[i], want[i])
+		}
+	}
+	// Volume weighted standard deviation of 10 × 100 and 20 × 300 around 17.5
+	if dev := math.Sqrt((100*7.5*7.5 + 300*2.5*2.5) / 400); math.Abs(got.Upper[2]-(17.5+dev)) > 1e-9 || math.Abs(got.Lower[2]-(17.5-dev)) > 1e-9 {
+		t.Errorf("bands: got %f and %f, want 17.5 ± %f", got.Upper[2], got.Lower[2], dev)
+	}
+}
+
+// TestMismatchedLengths checks indicators taking several series return a value per
+// index of the shortest one instead of panicking.
+func TestMismatchedLengths(t *testing.T) {
+	long := []float64{5, 6, 7, 8, 9, 10}
+	short := []float64{4, 5, 6}
+	tests := map[string]func() int{
+		"VWMA":       func() int { return len(indicators.VWMA(2, long, short)) },
+		"Stochastic": func() int { return len(indicators.Stochastic(2, 1, 1, long, short, long).K) },
+		"CCI":        func() int { return len(indicators.CCI(2, long, long, short)) },
+		"WilliamsR":  func() int { return len(indicators.WilliamsR(2, short, long, long)) },
+		"TrueRange":  func() int { return len(indicators.TrueRange(long, short, long)) },
+		"ATR":        func() int { return len(indicators.ATR(2, long, long, short)) },
+		"NATR":       func() int { return len(indicators.NATR(2, long, long, short)) },
+		"Keltner":    func() int { return len(indicators.Keltner(2, 2, 1, short, long, long).Upper) },
+		"Donchian":   func() int { return len(indicators.Donchian(2, long, short).Middle) },
+		"OBV":        func() int { return len(indicators.OBV(long, short)) },
+		"MFI":        func() int { return len(indicators.MFI(2, long, long, long, short)) },
+		"AD":         func() int { return len(indicators.AD(long, short, long, long)) },
+		"CMF":        func() int { return len(indicators.CMF(2, long, long, short, long)) },
+	}
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := fn(); got != len(short) {
+				t.Errorf("got %d values, want %d", got, len(short))
+			}
+		})
+	}
+
+	// Only the volume of bars with a high and low counts
+	p := indicators.VolumeProfile(2, indicators.OHLCV{High: long, Low: short, Volume: long})
+	var volume float64
+	for _, level := range p.Levels {
+		volume += level.Volume
+	}
+	if volume != 5+6+7 {
+		t.Errorf("volume profile: got %f volume, want %d", volume, 5+6+7)
+	}
+}
+
+// TestVolumeIndicators checks volume indicators against reference values. Values
+// from TA-Lib's lookback on are its outputs, the ones before it are the warm-up
+// computed over the values available so far. CMF isn't in TA-Lib so its values come
+// from its definition.
+func TestVolumeIndicators(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+	}{
+		{
+			name: "OBV",
+			got:  indicators.OBV(prices, volumes),
+			// TA-Lib minus the first volume as OBV starts at 0 here
+			want: []float64{
+				0, -900, 600, -2400, -1600,
+				-500, 500, 3000, 4800, 5500,
+				4200, 5800, 4900, 7000, 7000,
+				5600, 6700, 7500, 5600, 3000,
+			},
+		},
+		{
+			name: "MFI",
+			got:  indicators.MFI(5, highs, lows, prices, volumes),
+			// TA-Lib from index 5
+			want: []float64{
+				50, 0, 0, 0, 12.97696755,
+				26.29251651, 39.64078669, 64.93811193, 100, 100,
+				82.07494037, 83.49018551, 65.12135625, 66.79410026, 68.26043859,
+				67.22884962, 47.80720262, 61.01914911, 29.09843363, 10.32662423,
+			},
+		},
+		{
+			name: "AD",
+			got:  indicators.AD(highs, lows, prices, volumes),
+			// TA-Lib
+			want: []float64{
+				80, -136, 278.89361702, -1146.10638298, -708.77304965,
+				-232.10638298, 101.22695035, 964.86331399, 2044.86331399, 2128.86331399,
+				2336.86331399, 2848.86331399, 2428.86331399, 3808.86331399, 3928.86331399,
+				3368.86331399, 3393.30775844, 3640.58048571, 2753.91381904, 1367.24715237,
+			},
+		},
+		{
+			name: "CMF",
+			got:  indicators.CMF(5, highs, lows, prices, volumes),
+			// sum(CLV × volume) / sum(volume) over 5 bars from index 4
+			want: []float64{
+				0.06666667, -0.0647619, 0.07747045, -0.17365248, -0.09578014,
+				-0.0427543, 0.0320577, 0.08166306, 0.44319024, 0.39966709,
+				0.35191366, 0.34780207, 0.23238095, 0.26727273, 0.26086957,
+				0.14742857, 0.08376068, 0.18933081, -0.17015314, -0.32841233,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValues(t, tt.got, tt.want)
+		})
+	}
+
+	t.Run("no volume", func(t *testing.T) {
+		none := make([]float64, len(prices))
+		assertValues(t, indicators.OBV(prices, none), none)
+		assertValues(t, indicators.AD(highs, lows, prices, none), none)
+		assertValues(t, indicators.CMF(5, highs, lows, prices, none), none)
+		for i, v := range indicators.MFI(5, highs, lows, prices, none) {
+			if v != 50 {
+				t.Errorf("MFI %d: got %f, want 50", i, v)
+			}
+		}
+	})
+}
+
+func TestVolumeProfile(t *testing.T) {
+	ohlcv := indicators.OHLCV{
+		High:   []float64{12, 13, 14, 13},
+		Low:    []float64{10, 11, 14, 10},
+		Volume: []float64{100, 50, 30, 0},
+	}
+	got := indicators.VolumeProfile(4, ohlcv)
+
+	// Levels are 1 high from 10 to 14. The first bar is spread over [10, 12], the
+	// second one over [11, 13] and the flat one goes in the top level. The last bar
+	// has no volume.
+	want := []indicators.VolumeLevel{
+		{Low: 10, High: 11, Volume: 50},
+		{Low: 11, High: 12, Volume: 75},
+		{Low: 12, High: 13, Volume: 25},
+		{Low: 13, High: 14, Volume: 30},
+	}
+	if len(got.Levels) != len(want) {
+		t.Fatalf("got %d levels, want %d", len(got.Levels), len(want))
+	}
+	for i := range want {
+		if l := got.Levels[i]; math.Abs(l.Low-want[i].Low) > 1e-9 || math.Abs(l.High-want[i].High) > 1e-9 || math.Abs(l.Volume-want[i].Volume) > 1e-9 {
+			t.Errorf("level %d: got %+v, want %+v", i, l, want[i])
+		}
+	}
+	if got.POC != 1 {
+		t.Errorf("POC: got %d, want 1", got.POC)
+	}
+
+	t.Run("flat range", func(t *testing.T) {
+		flat := []float64{10, 10}
+		got := indicators.VolumeProfile(3, indicators.OHLCV{High: flat, Low: flat, Volume: []float64{5, 7}})
+		if got.POC != 0 || got.Levels[0].Volume != 12 {
+			t.Errorf("got %+v, want all of the volume in the first level", got)
+		}
+	})
+
+	t.Run("no volume", func(t *testing.T) {
+		for name, ohlcv := range map[string]indicators.OHLCV{
+			"empty": {},
+			"zero":  {High: []float64{12}, Low: []float64{10}, Volume: []float64{0}},
+		} {
+			if got := indicators.VolumeProfile(4, ohlcv); got.POC != -1 {
+				t.Errorf("%s: got POC %d, want -1", name, got.POC)
+			}
+		}
+	})
+}
@@ -1,42 +1,72 @@
-// Design:
-// bt := NewBt(BtOptions{ Symbol: "APPL", Cash: 10000.00, Comission: ...})
-// bt.Run(Strategy(StrategyOptions{}))
+// Command maximus runs backtests from the command line.
+//
+//	maximus run -symbols SPY -timeframe 5min -start 2024-02-10 -end 2024-02-21 -param period=20
+//	maximus optimize -config spy.yaml -grid period=10:50:5 -metric sharpe
+//	maximus fetch -symbols SPY,QQQ -timeframe 1day -start 2023-01-01 -out bars
+//
+// Options can be given in a YAML or JSON file with `-config` and flags override
+// them. Alpaca credentials are read from the environment or a .env file.
 package main
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"time"
+	"strings"
 
-	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
 	"github.com/joho/godotenv"
-	"github.com/pedropmedina/maximus/backtest"
 	"github.com/pedropmedina/maximus/strategies"
 )
 
+type command struct {
+	name  string
+	usage string
+	run   func(cfg Config) error
+}
+
+var commands = []command{
+	{"run", "run a strategy and print its stats", runCmd},
+	{"optimize", "run a strategy on a grid of parameters and rank the runs", optimizeCmd},
+	{"fetch", "download bars from alpaca as a csv file per symbol", fetchCmd},
+}
+
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	// Alpaca marketdata client
-	c := marketdata.NewClient(marketdata.ClientOpts{
-		BaseURL:   os.Getenv("APCA_API_DATA_URL"),
-		APIKey:    os.Getenv("APCA_API_KEY_ID"),
-		APISecret: os.Getenv("APCA_API_SECRET_KEY"),
-	})
+	// .env is optional as credentials may already be in the environment
+	_ = godotenv.Load()
 
-	// 5 min marketdata for SPY
-	bars, err := c.GetBars("SPY", marketdata.GetBarsRequest{
-		TimeFrame: marketdata.NewTimeFrame(5, marketdata.Min),
-		Start:     time.Date(2024, 2, 10, 9, 30, 0, 0, time.UTC),
-		End:       time.Date(2024, 2, 21, 16, 30, 0, 0, time.UTC),
-	})
-	if err != nil {
-		panic(err)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		cfg, err := parseConfig(name, args)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err := cmd.run(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
 	}
+	usage()
+	os.Exit(2)
+}
 
-	// maximus.New(bars, { Symbol: "APPL", ...}).Strategy().Run().Summary()
-	backtest := backtest.New(bars, backtest.Opts{}).Strategy(strategies.CloseOverSMA(14))
-	backtest.Run().Summary()
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: maximus <command> [flags]\n\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nstrategies: %s\n", strings.Join(strategies.Names(), ", "))
+	fmt.Fprintln(os.Stderr, "\nrun `maximus <command> -h` for the command's flags")
 }
@@ -0,0 +1,69 @@
+package strategies
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/pedropmedina/maximus/backtest"
+)
+
+// Definition describes a strategy that can be looked up by name e.g. from the
+// command line.
+type Definition struct {
+	// Description is a one line summary of the strategy.
+	Description string
+	// Params are the strategy's parameters along with their default values.
+	Params backtest.Params
+	// New returns the strategy for the given parameters. Parameters not given
+	// fall back to their defaults.
+	New func(p backtest.Params) func(s *backtest.Strategy)
+}
+
+var registry = map[string]Definition{
+	"close_over_sma": {
+		Description: "Buys when a bar closes above the SMA and sells when it closes below it",
+		Params:      backtest.Params{"period": 14},
+		New: func(p backtest.Params) func(s *backtest.Strategy) {
+			return CloseOverSMA(p.Int("period"))
+		},
+	},
+}
+
+// Register makes a strategy available under the given name replacing any strategy
+// previously registered with it.
+func Register(name string, def Definition) {
+	registry[name] = def
+}
+
+// Lookup returns the strategy registered under the given name.
+func Lookup(name string) (Definition, bool) {
+	def, ok := registry[name]
+	if !ok {
+		return Definition{}, false
+	}
+	// Params are wrapped so that missing ones fall back to their defaults
+	return Definition{
+		Description: def.Description,
+		Params:      def.Params,
+		New: func(p backtest.Params) func(s *backtest.Strategy) {
+			params := make(backtest.Params, len(def.Params)+len(p))
+			for k, v := range def.Params {
+				params[k] = v
+			}
+			for k, v := range p {
+				params[k] = v
+			}
+			return def.New(params)
+		},
+	}, true
+}
+
+// Names returns the names of every registered strategy sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, cmp.Compare[string])
+	return names
+}
@@ -6,21 +6,36 @@ import (
 )
 
 // CloseOverSMA sells when the bar's close price is below the SMA and
-// buys when it closes above SMA.
+// buys when it closes above SMA. It trades every symbol in the backtest.
 func CloseOverSMA(period int) func(s *backtest.Strategy) {
 	return func(s *backtest.Strategy) {
-		sma := indicators.SMA(period, s.Data.Prices(backtest.Close))
-		bar := s.Data.LastBar()
-		ma := sma[len(sma)-1]
+		for _, symbol := range s.Symbols {
+			sma := s.I(symbol, "sma", func() backtest.Indicator {
+				return indicators.NewSMAStream(period, backtest.Close)
+			})
+			// There's no closed bar yet on ticks of the first bar
+			if len(sma) == 0 {
+				continue
+			}
+			bar := s.Data[symbol].LastBar()
+			ma := sma[len(sma)-1]
 
-		// Buy signal
-		if bar.Open < ma && bar.Close > ma {
-			s.Buy(backtest.TradeOpts{})
-		}
+			// Orders are only rejected here when they're invalid e.g. fractional
+			// sizes so we stop the run instead of trading without them.
+			var err error
+			// Buy signal
+			if bar.Open < ma && bar.Close > ma {
+				_, err = s.Buy(symbol, backtest.TradeOpts{})
+			}
 
-		// Sell signal
-		if bar.Open > ma && bar.Close < ma {
-			s.Sell(backtest.TradeOpts{})
+			// Sell signal
+			if bar.Open > ma && bar.Close < ma {
+				_, err = s.Sell(symbol, backtest.TradeOpts{})
+			}
+			if err != nil {
+				s.Fail(err)
+				return
+			}
 		}
 	}
 }
@@ -0,0 +1,34 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedropmedina/maximus/backtest"
+	"github.com/pedropmedina/maximus/strategies"
+)
+
+func TestCloseOverSMA(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var bars []backtest.Bar
+	var ticks []backtest.Tick
+	for i, c := range []float64{10, 10, 10, 12} {
+		open := 10.0
+		bars = append(bars, backtest.Bar{Timestamp: start.AddDate(0, 0, i), Open: open, High: max(open, c), Low: open, Close: c})
+		ticks = append(ticks, backtest.Tick{Timestamp: bars[i].Timestamp.Add(time.Hour), Kind: backtest.TradeTick, Price: c, Size: 1})
+	}
+
+	bt, err := backtest.New(map[string][]backtest.Bar{"X": bars}, backtest.WithTicks(map[string][]backtest.Tick{"X": ticks}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Called on the tick of the first bar before any bar closed
+	res, err := bt.Strategy("sma", strategies.CloseOverSMA(2), backtest.WithTickEvents()).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The last bar opens below its SMA of 11 and closes above it
+	if len(res.Orders) != 1 || res.Orders[0].Side != backtest.Buy {
+		t.Errorf("got %d orders, want a single buy", len(res.Orders))
+	}
+}